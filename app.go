@@ -0,0 +1,121 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/creasty/defaults"
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/rs/zerolog/log"
+)
+
+// AppOptions is the config of NewApp and Run
+type AppOptions struct {
+	AppName string `default:"app"`
+
+	// Addr is the address to listen on, e.g. ":8000"
+	Addr string `default:":8000"`
+
+	// DrainTimeout is the max duration to wait for active connections
+	// to finish after a shutdown signal is received
+	DrainTimeout time.Duration `default:"10s"`
+
+	// ShowStartupMessage shows the fiber startup banner
+	ShowStartupMessage bool
+
+	// ResponseMode decides whether response helpers wrap data in an envelope
+	ResponseMode ResponseMode
+}
+
+// ShutdownHook is called after the server stops accepting connections
+type ShutdownHook func() error
+
+var (
+	shutdownHooks      []ShutdownHook
+	shutdownHooksMutex sync.Mutex
+)
+
+// RegisterShutdownHook registers a hook to be called on shutdown.
+// Hooks are called in reverse order of registration, like defer.
+func RegisterShutdownHook(hook ShutdownHook) {
+	shutdownHooksMutex.Lock()
+	defer shutdownHooksMutex.Unlock()
+	shutdownHooks = append(shutdownHooks, hook)
+}
+
+// NewApp creates a fiber app with ErrorHandler, MiddlewareCustomLogger, recovery,
+// MiddlewareGetUserID and MiddlewareResponseMode registered
+func NewApp(options AppOptions) *fiber.App {
+	_ = defaults.Set(&options)
+
+	app := fiber.New(fiber.Config{
+		AppName:               options.AppName,
+		ErrorHandler:          ErrorHandler,
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+		DisableStartupMessage: !options.ShowStartupMessage,
+	})
+
+	// logger is registered before recover, so that requests which panic are logged too
+	app.Use(MiddlewareCustomLogger)
+	app.Use(recover.New(recover.Config{EnableStackTrace: true, StackTraceHandler: StackTraceHandler}))
+	app.Use(MiddlewareGetUserID)
+	app.Use(MiddlewareResponseMode(options.ResponseMode))
+
+	return app
+}
+
+// Run starts the app and blocks until SIGINT or SIGTERM is received.
+// Then it waits at most DrainTimeout for active connections and runs shutdown hooks.
+func Run(app *fiber.App, options AppOptions) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	return serve(ctx, app, options)
+}
+
+func serve(ctx context.Context, app *fiber.App, options AppOptions) error {
+	_ = defaults.Set(&options)
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(options.Addr)
+	}()
+
+	var err error
+	select {
+	case err = <-listenErr:
+		// listen failed, no need to shut down the server
+		log.Err(err).Str("addr", options.Addr).Msg("listen error")
+	case <-ctx.Done():
+		log.Info().Msg("shutting down server")
+		err = app.ShutdownWithTimeout(options.DrainTimeout)
+		if err != nil {
+			log.Err(err).Msg("shutdown server error")
+		}
+	}
+
+	return errors.Join(err, runShutdownHooks())
+}
+
+func runShutdownHooks() error {
+	shutdownHooksMutex.Lock()
+	defer shutdownHooksMutex.Unlock()
+
+	var errs []error
+	for i := len(shutdownHooks) - 1; i >= 0; i-- {
+		if err := shutdownHooks[i](); err != nil {
+			log.Err(err).Msg("shutdown hook error")
+			errs = append(errs, err)
+		}
+	}
+	shutdownHooks = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServe(t *testing.T) {
+	app := NewApp(AppOptions{AppName: "test"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.Hooks().OnListen(func(_ fiber.ListenData) error {
+		cancel()
+		return nil
+	})
+
+	var order []int
+	RegisterShutdownHook(func() error {
+		order = append(order, 1)
+		return nil
+	})
+	RegisterShutdownHook(func() error {
+		order = append(order, 2)
+		return nil
+	})
+
+	err := serve(ctx, app, AppOptions{Addr: "127.0.0.1:0"})
+	assert.Nil(t, err)
+	assert.EqualValues(t, []int{2, 1}, order)
+}
+
+func TestNewAppLogsPanic(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&buffer)
+	defer func() { log.Logger = logger }()
+
+	app := NewApp(AppOptions{})
+	app.Get("/panic", func(_ *fiber.Ctx) error {
+		panic("boom")
+	})
+	RegisterApp(app)
+
+	DefaultTester.Get(t, RequestConfig{Route: "/panic", ExpectedStatus: 500})
+	assert.Contains(t, buffer.String(), `"status_code":500`)
+	assert.Contains(t, buffer.String(), `"origin_url":"/panic"`)
+}
@@ -0,0 +1,302 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AsyncValidationFunc validates a field value with I/O, e.g. checking uniqueness against database.
+// It returns false if the value is invalid, or an error if the check itself fails.
+type AsyncValidationFunc func(ctx context.Context, value any, param string) (bool, error)
+
+// AsyncValidationTimeout limits the time of all async validations of a request
+var AsyncValidationTimeout = 5 * time.Second
+
+// AsyncValidationConcurrency limits the number of async validations of a request running at the same time
+var AsyncValidationConcurrency = 8
+
+var (
+	asyncValidationsMu sync.RWMutex
+	asyncValidations   = make(map[string]AsyncValidationFunc)
+)
+
+// RegisterAsyncValidation registers an async validation used by `async` tag as `async:"tag=param"`.
+// Multiple validations are separated by comma. Zero values are skipped, use `validate:"required"` for them.
+// Like `validate` tag, validations after `dive` apply to elements of slices, arrays and maps,
+// and fields of nested structs are validated too. Messages of the tag can be registered by RegisterMessage.
+//
+//	RegisterAsyncValidation("division_exists", func(ctx context.Context, value any, _ string) (bool, error) {
+//		var count int64
+//		err := DB.WithContext(ctx).Model(&Division{}).Where("id = ?", value).Count(&count).Error
+//		return count > 0, err
+//	})
+//
+//	type CreateHoleRequest struct {
+//		DivisionID int   `json:"division_id" validate:"required" async:"division_exists"`
+//		TagIDs     []int `json:"tag_ids" async:"dive,tag_exists"`
+//	}
+func RegisterAsyncValidation(tag string, fn AsyncValidationFunc) error {
+	if tag == "" || tag == "dive" || strings.ContainsAny(tag, ",= \t\n") {
+		return fmt.Errorf("async validation tag %q is invalid", tag)
+	}
+	if fn == nil {
+		return fmt.Errorf("async validation %s is nil", tag)
+	}
+	asyncValidationsMu.Lock()
+	defer asyncValidationsMu.Unlock()
+	asyncValidations[tag] = fn
+	return nil
+}
+
+type asyncTask struct {
+	fn      AsyncValidationFunc
+	value   reflect.Value
+	tag     string
+	param   string
+	invalid bool
+	err     error
+
+	field   reflect.StructField
+	element asyncElement
+}
+
+// asyncElement is a value to validate in model, a field or an element of a field
+type asyncElement struct {
+	namespace   string // struct namespace without the type name, e.g. Tags[0].Name
+	structField string // e.g. Tags[0]
+	name        string // name in json, e.g. tags[0]
+	path        string // JSON path, e.g. tags[0].name
+}
+
+// asyncCollector collects async tasks in model, skipping elements whose namespaces are in skip
+type asyncCollector struct {
+	tasks []*asyncTask
+	skip  map[string]bool
+	err   error
+}
+
+// ValidateAsync runs async validations of `async` tags in model in parallel,
+// at most AsyncValidationConcurrency at a time, with AsyncValidationTimeout.
+// Invalid fields are reported as *ErrorDetail, a timeout as 503 Service Unavailable,
+// and other errors of the checks are joined and returned as is.
+func ValidateAsync(ctx context.Context, model any) error {
+	return withAsyncValidation(ctx, model, nil)
+}
+
+// validateAsync runs async validations on fields except those in skip, keyed by struct namespace
+func validateAsync(ctx context.Context, model any, skip map[string]bool) (ErrorDetail, error) {
+	value, ok := structValue(model)
+	if !ok {
+		return nil, nil
+	}
+
+	collector := asyncCollector{skip: skip}
+	asyncValidationsMu.RLock()
+	collector.collectStruct(value, asyncElement{})
+	asyncValidationsMu.RUnlock()
+	if collector.err != nil {
+		return nil, collector.err
+	}
+	tasks := collector.tasks
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, AsyncValidationTimeout)
+	defer cancel()
+
+	// a fixed number of workers run the tasks, those not started before the timeout are not run
+	queue := make(chan *asyncTask, len(tasks))
+	for _, task := range tasks {
+		queue <- task
+	}
+	close(queue)
+
+	var wg sync.WaitGroup
+	for i := 0; i < Min(Max(AsyncValidationConcurrency, 1), len(tasks)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range queue {
+				if task.err = ctx.Err(); task.err != nil {
+					continue
+				}
+				valid, err := task.fn(ctx, task.value.Interface(), task.param)
+				task.invalid, task.err = !valid && err == nil, err
+			}
+		}()
+	}
+	wg.Wait()
+
+	var errorDetail ErrorDetail
+	var errs []error
+	for _, task := range tasks {
+		if task.err != nil {
+			errs = append(errs, task.err)
+		}
+		if !task.invalid {
+			continue
+		}
+		errorDetail = append(errorDetail, &ErrorDetailElement{
+			Tag:         task.tag,
+			Field:       task.element.name,
+			Kind:        task.value.Kind(),
+			Value:       task.value.Interface(),
+			Param:       task.param,
+			StructField: task.element.structField,
+			Label:       fieldLabel(task.field),
+			Path:        task.element.path,
+			Pointer:     jsonPointer(task.element.path),
+
+			structNamespace: task.element.namespace,
+		})
+	}
+	err := errors.Join(errs...)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errorDetail, ServiceUnavailable("async validation timeout")
+	}
+	return errorDetail, err
+}
+
+// collectStruct collects tasks of fields in struct value
+func (c *asyncCollector) collectStruct(value reflect.Value, parent asyncElement) {
+	for i := 0; i < value.NumField() && c.err == nil; i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := jsonTagName(field)
+		element := asyncElement{
+			namespace:   joinNamespace(parent.namespace, field.Name),
+			structField: field.Name,
+			name:        name,
+			path:        parent.path,
+		}
+		if element.name == "" {
+			element.name = field.Name
+		}
+		// fields of embedded structs without json names are promoted in json
+		if !field.Anonymous || name != "" {
+			element.path = joinNamespace(parent.path, element.name)
+		}
+
+		var rules []string
+		if tag := field.Tag.Get("async"); tag != "" {
+			rules = strings.Split(tag, ",")
+		}
+		c.collect(value.Field(i), field, element, rules)
+	}
+}
+
+// collect collects tasks of value by rules, rules after dive apply to its elements
+func (c *asyncCollector) collect(value reflect.Value, field reflect.StructField, element asyncElement, rules []string) {
+	if c.skip[element.namespace] {
+		return
+	}
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if _, ok := optionalValueType(value.Type()); ok {
+		// absent and null Optional are skipped like zero values
+		if value, ok = optionalValue(value); !ok {
+			return
+		}
+	}
+
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name == "dive" {
+			c.collectElements(value, field, element, rules[i+1:])
+			return
+		}
+		if value.IsZero() {
+			continue
+		}
+		fn, ok := asyncValidations[name]
+		if !ok {
+			c.err = fmt.Errorf("async validation %s is not registered", name)
+			return
+		}
+		c.tasks = append(c.tasks, &asyncTask{fn: fn, value: value, tag: name, param: param, field: field, element: element})
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type() != timeType && value.Type() != customTimeType {
+			c.collectStruct(value, element)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		c.collectElements(value, field, element, nil)
+	}
+}
+
+// collectElements collects tasks of elements in slices, arrays and maps by rules
+func (c *asyncCollector) collectElements(value reflect.Value, field reflect.StructField, parent asyncElement, rules []string) {
+	index := func(key string, isMap bool) asyncElement {
+		element := asyncElement{
+			namespace:   parent.namespace + "[" + key + "]",
+			structField: parent.structField + "[" + key + "]",
+			name:        parent.name + "[" + key + "]",
+			path:        parent.path + "[" + key + "]",
+		}
+		if isMap && identifierPattern.MatchString(key) {
+			element.path = parent.path + "." + key
+		} else if isMap {
+			element.path = parent.path + "[" + strconv.Quote(key) + "]"
+		}
+		return element
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len() && c.err == nil; i++ {
+			c.collect(value.Index(i), field, index(strconv.Itoa(i), false), rules)
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			if c.err != nil {
+				break
+			}
+			c.collect(value.MapIndex(key), field, index(fmt.Sprint(key.Interface()), true), rules)
+		}
+	}
+}
+
+func joinNamespace(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// withAsyncValidation runs async validations after static validation whose result is err,
+// skipping fields that are already invalid, and merges the results
+func withAsyncValidation(ctx context.Context, model any, err error) error {
+	skip := make(map[string]bool)
+	if err != nil {
+		errorDetail, ok := err.(*ErrorDetail)
+		if !ok {
+			return err
+		}
+		for _, element := range *errorDetail {
+			skip[element.structNamespace] = true
+		}
+	}
+
+	elements, asyncErr := validateAsync(ctx, model, skip)
+	if asyncErr != nil {
+		return asyncErr
+	}
+	return appendErrorDetail(err, elements...)
+}
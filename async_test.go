@@ -0,0 +1,192 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testAsyncRequest struct {
+	Username   string `json:"username" validate:"max=10" async:"unique_username"`
+	DivisionID int    `json:"division_id" async:"division_exists=active,slow"`
+}
+
+func TestValidateAsync(t *testing.T) {
+	var calls atomic.Int32
+	RegisterAsyncValidation("unique_username", func(_ context.Context, value any, _ string) (bool, error) {
+		calls.Add(1)
+		if value == "error" {
+			return false, errors.New("database error")
+		}
+		return value != "taken", nil
+	})
+	RegisterAsyncValidation("division_exists", func(_ context.Context, value any, param string) (bool, error) {
+		calls.Add(1)
+		return param == "active" && value.(int) <= 10, nil
+	})
+	RegisterAsyncValidation("slow", func(ctx context.Context, _ any, _ string) (bool, error) {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+			return true, nil
+		}
+	})
+	RegisterMessage(LanguageZH, "unique_username", "用户名已被占用")
+
+	assert.Nil(t, ValidateAsync(context.Background(), &testAsyncRequest{Username: "a", DivisionID: 1}))
+	assert.EqualValues(t, 2, calls.Load())
+
+	err := ValidateAsync(context.Background(), &testAsyncRequest{Username: "taken", DivisionID: 11})
+	assert.EqualValues(t, "用户名已被占用, DivisionID格式不正确", err.Error())
+
+	err = ValidateAsync(context.Background(), &testAsyncRequest{Username: "error"})
+	assert.EqualError(t, err, "database error")
+
+	timeout := AsyncValidationTimeout
+	AsyncValidationTimeout = 10 * time.Millisecond
+	defer func() { AsyncValidationTimeout = timeout }()
+	err = ValidateAsync(context.Background(), &testAsyncRequest{DivisionID: 1})
+	var httpError *HttpError
+	assert.ErrorAs(t, err, &httpError)
+	assert.EqualValues(t, 503, httpError.Code)
+}
+
+type testAsyncTag struct {
+	Name string `json:"name" validate:"max=5" async:"unique_tag"`
+}
+
+type AsyncEmbedded struct {
+	Slug string `json:"slug" async:"unique_tag"`
+}
+
+type testAsyncNestedRequest struct {
+	AsyncEmbedded
+	Tag     testAsyncTag            `json:"tag"`
+	Tags    []testAsyncTag          `json:"tags" validate:"dive"`
+	TagIDs  []int                   `json:"tag_ids" async:"dive,tag_exists"`
+	TagMap  map[string]testAsyncTag `json:"tag_map"`
+	Unknown string                  `json:"unknown" async:"not_registered"`
+	Title   Optional[string]        `json:"title" async:"unique_tag"`
+}
+
+func TestValidateAsyncNested(t *testing.T) {
+	assert.Nil(t, RegisterAsyncValidation("unique_tag", func(_ context.Context, value any, _ string) (bool, error) {
+		return value != "taken", nil
+	}))
+	assert.Nil(t, RegisterAsyncValidation("tag_exists", func(_ context.Context, value any, _ string) (bool, error) {
+		return value.(int) <= 10, nil
+	}))
+
+	err := ValidateAsync(context.Background(), &testAsyncNestedRequest{
+		AsyncEmbedded: AsyncEmbedded{Slug: "taken"},
+		Tag:           testAsyncTag{Name: "taken"},
+		Tags:          []testAsyncTag{{Name: "a"}, {Name: "taken"}},
+		TagIDs:        []int{1, 11},
+		TagMap:        map[string]testAsyncTag{"a.b": {Name: "taken"}},
+		Title:         Some("taken"),
+	})
+	var errorDetail *ErrorDetail
+	assert.ErrorAs(t, err, &errorDetail)
+	var paths, namespaces []string
+	for _, element := range *errorDetail {
+		paths = append(paths, element.Path)
+		namespaces = append(namespaces, element.structNamespace)
+	}
+	assert.EqualValues(t, []string{"slug", "tag.name", "tags[1].name", "tag_ids[1]", `tag_map["a.b"].name`, "title"}, paths)
+	assert.EqualValues(t, []string{"AsyncEmbedded.Slug", "Tag.Name", "Tags[1].Name", "TagIDs[1]", "TagMap[a.b].Name", "Title"}, namespaces)
+	assert.EqualValues(t, "taken", (*errorDetail)[5].Value)
+	assert.EqualValues(t, "/tag_map/a.b/name", (*errorDetail)[4].Pointer)
+
+	// elements with static errors are skipped by namespace
+	request := testAsyncNestedRequest{Tags: []testAsyncTag{{Name: "taken_taken"}, {Name: "taken"}}}
+	err = withAsyncValidation(context.Background(), &request, ValidateStruct(&request))
+	assert.ErrorAs(t, err, &errorDetail)
+	assert.Len(t, *errorDetail, 2)
+	assert.EqualValues(t, "max", (*errorDetail)[0].Tag)
+	assert.EqualValues(t, "Tags[0].Name", (*errorDetail)[0].structNamespace)
+	assert.EqualValues(t, "unique_tag", (*errorDetail)[1].Tag)
+	assert.EqualValues(t, "Tags[1].Name", (*errorDetail)[1].structNamespace)
+
+	// unregistered tags are reported as errors instead of panics
+	err = ValidateAsync(context.Background(), &testAsyncNestedRequest{Unknown: "a"})
+	assert.EqualError(t, err, "async validation not_registered is not registered")
+
+	assert.NotNil(t, RegisterAsyncValidation("", func(context.Context, any, string) (bool, error) { return true, nil }))
+	assert.NotNil(t, RegisterAsyncValidation("a,b", func(context.Context, any, string) (bool, error) { return true, nil }))
+	assert.NotNil(t, RegisterAsyncValidation("dive", func(context.Context, any, string) (bool, error) { return true, nil }))
+	assert.NotNil(t, RegisterAsyncValidation("nil", nil))
+}
+
+func TestValidateBodyAsync(t *testing.T) {
+	RegisterAsyncValidation("unique_username", func(_ context.Context, value any, _ string) (bool, error) {
+		return value != "taken", nil
+	})
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/users", func(c *fiber.Ctx) error {
+		var body struct {
+			Username string `json:"username" validate:"max=10" async:"unique_username"`
+			Nickname string `json:"nickname" validate:"required"`
+		}
+		if err := ValidateBody(c, &body); err != nil {
+			return err
+		}
+		return c.SendStatus(201)
+	})
+	RegisterApp(app)
+
+	DefaultTester.Post(t, RequestConfig{Route: "/users", RequestBody: Map{"username": "a", "nickname": "a"}})
+
+	var httpError HttpError
+	DefaultTester.Post(t, RequestConfig{
+		Route:          "/users",
+		RequestBody:    Map{"username": "taken"},
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.Len(t, *httpError.Detail, 2)
+	assert.EqualValues(t, "unique_username", (*httpError.Detail)[1].Tag)
+
+	// fields with static errors are not checked again
+	httpError = HttpError{}
+	DefaultTester.Post(t, RequestConfig{
+		Route:          "/users",
+		RequestBody:    Map{"username": "taken_taken_taken", "nickname": "a"},
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.Len(t, *httpError.Detail, 1)
+	assert.EqualValues(t, "max", (*httpError.Detail)[0].Tag)
+}
+
+func TestValidateAsyncConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	assert.Nil(t, RegisterAsyncValidation("count_running", func(_ context.Context, _ any, _ string) (bool, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return true, nil
+	}))
+
+	concurrency := AsyncValidationConcurrency
+	AsyncValidationConcurrency = 2
+	defer func() { AsyncValidationConcurrency = concurrency }()
+
+	request := struct {
+		IDs []int `json:"ids" async:"dive,count_running"`
+	}{IDs: []int{1, 2, 3, 4, 5, 6}}
+	assert.Nil(t, ValidateAsync(context.Background(), &request))
+	assert.EqualValues(t, 2, peak.Load())
+}
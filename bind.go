@@ -0,0 +1,170 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/creasty/defaults"
+	"github.com/gofiber/fiber/v2"
+)
+
+// bindSources are the struct tags read by Bind besides body, and how to read them
+var bindSources = []struct {
+	tag    string
+	values func(c *fiber.Ctx, name string) []string
+}{
+	{"param", func(c *fiber.Ctx, name string) []string {
+		return nonEmpty(c.Params(name))
+	}},
+	{"query", func(c *fiber.Ctx, name string) []string {
+		var values []string
+		for _, value := range c.Context().QueryArgs().PeekMulti(name) {
+			values = append(values, string(value))
+		}
+		return values
+	}},
+	{"header", func(c *fiber.Ctx, name string) []string {
+		return nonEmpty(c.Get(name))
+	}},
+	{"cookie", func(c *fiber.Ctx, name string) []string {
+		return nonEmpty(c.Cookies(name))
+	}},
+}
+
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// Bind fills req from path params, query, headers, cookies and body in one go, by struct tags:
+// `param`, `query`, `header`, `cookie`, and `json` or other tags supported by ValidateBody.
+// Body is parsed first, so other sources take precedence.
+// Then defaults are set and the whole struct is validated once, all errors are aggregated into *ErrorDetail.
+//
+//	type UpdateFloorRequest struct {
+//		ID      int    `param:"id" validate:"min=1"`
+//		Version int    `header:"If-Match"`
+//		Content string `json:"content" validate:"required"`
+//	}
+func Bind(c *fiber.Ctx, req any) error {
+	value, ok := structValue(req)
+	if !ok {
+		return InternalServerError("bind: req should be a pointer to struct")
+	}
+
+	// body
+	if body := c.Body(); len(body) > 0 {
+		if StrictBody && strings.HasSuffix(mediaType(c), "json") {
+			errorDetail, err := checkStrictJSON(body, reflect.TypeOf(req))
+			if err != nil {
+				return err
+			}
+			if len(errorDetail) > 0 {
+				return &errorDetail
+			}
+		}
+		if err := parseBody(c, req); err != nil {
+			return err
+		}
+	}
+
+	// param, query, header and cookie, type errors are reported with validation errors
+	var typeErrors ErrorDetail
+	for _, field := range reflect.VisibleFields(value.Type()) {
+		if !field.IsExported() {
+			continue
+		}
+		for _, source := range bindSources {
+			name, _, _ := strings.Cut(field.Tag.Get(source.tag), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			values := source.values(c, name)
+			if len(values) == 0 {
+				continue
+			}
+			if err := setValueFromStrings(value.FieldByIndex(field.Index), values); err != nil {
+				typeErrors = append(typeErrors, &ErrorDetailElement{
+					Tag:         "type",
+					Field:       name,
+					Kind:        field.Type.Kind(),
+					Value:       strings.Join(values, ","),
+					Param:       source.tag,
+					StructField: field.Name,
+					Label:       fieldLabel(field),
+
+					structNamespace: indexNamespace(value.Type(), field.Index),
+				})
+			}
+		}
+	}
+
+	// normalize by mod tag
+	Normalize(req)
+
+	// set default value
+	err := defaults.Set(req)
+	if err != nil {
+		return err
+	}
+
+	// Validate, including filter, sort and uploaded files, then async validations
+	fileErrors, err := validateFiles(req)
+	if err != nil {
+		return err
+	}
+	err = appendErrorDetail(ValidateStruct(req), append(validateFilter(req), fileErrors...)...)
+	if len(typeErrors) > 0 {
+		err = mergeTypeErrors(typeErrors, err)
+	}
+	return withAsyncValidation(c.UserContext(), req, err)
+}
+
+// mergeTypeErrors puts type errors before the validation errors in err,
+// dropping validation errors of the same fields as their values are not set
+func mergeTypeErrors(typeErrors ErrorDetail, err error) error {
+	errorDetail, ok := err.(*ErrorDetail)
+	if err != nil && !ok {
+		return err
+	}
+	invalid := make(map[string]bool)
+	for _, element := range typeErrors {
+		invalid[element.structNamespace] = true
+	}
+	if errorDetail != nil {
+		for _, element := range *errorDetail {
+			if !invalid[element.structNamespace] {
+				typeErrors = append(typeErrors, element)
+			}
+		}
+	}
+	return &typeErrors
+}
+
+// indexNamespace returns the struct namespace of the field at index, e.g. PageRequest.Page
+func indexNamespace(typ reflect.Type, index []int) string {
+	names := make([]string, len(index))
+	for i := range index {
+		names[i] = typ.FieldByIndex(index[:i+1]).Name
+	}
+	return strings.Join(names, ".")
+}
+
+// setValueFromStrings sets multiple values into a slice field one by one,
+// or the first value into other fields
+func setValueFromStrings(value reflect.Value, values []string) error {
+	if value.Kind() != reflect.Slice || value.Addr().Type().Implements(textUnmarshalerType) || len(values) == 1 {
+		return setValueFromString(value, values[0])
+	}
+
+	slice := reflect.MakeSlice(value.Type(), len(values), len(values))
+	for i, s := range values {
+		if err := setValueFromString(slice.Index(i), s); err != nil {
+			return err
+		}
+	}
+	value.Set(slice)
+	return nil
+}
@@ -0,0 +1,87 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testBindRequest struct {
+	ID         int    `param:"id" json:"-" validate:"min=1"`
+	DivisionID []int  `query:"division_id" json:"-"`
+	Token      string `header:"X-Token" json:"-"`
+	Session    string `cookie:"session" json:"-"`
+	Content    string `json:"content" validate:"required"`
+	Likes      int    `json:"likes" default:"1"`
+	PageRequest
+}
+
+func TestBind(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Put("/floors/:id", func(c *fiber.Ctx) error {
+		var req testBindRequest
+		if err := Bind(c, &req); err != nil {
+			return err
+		}
+		return c.JSON(Map{
+			"id":          req.ID,
+			"division_id": req.DivisionID,
+			"token":       req.Token,
+			"session":     req.Session,
+			"content":     req.Content,
+			"likes":       req.Likes,
+			"page":        req.Page,
+			"size":        req.Size,
+		})
+	})
+	RegisterApp(app)
+
+	var response map[string]any
+	DefaultTester.Put(t, RequestConfig{
+		Route:          "/floors/3?division_id=1&division_id=2&page=2",
+		RequestHeaders: map[string]string{"X-Token": "token", "Cookie": "session=abc"},
+		RequestBody:    Map{"content": "hello"},
+		ResponseModel:  &response,
+	})
+	assert.EqualValues(t, Map{
+		"id":          3.0,
+		"division_id": []any{1.0, 2.0},
+		"token":       "token",
+		"session":     "abc",
+		"content":     "hello",
+		"likes":       1.0,
+		"page":        2.0,
+		"size":        10.0,
+	}, response)
+
+	// type errors
+	var httpError HttpError
+	DefaultTester.Put(t, RequestConfig{
+		Route:          "/floors/abc?division_id=x",
+		RequestBody:    Map{"content": "hello"},
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.EqualValues(t, "id类型不正确, division_id类型不正确", httpError.Message)
+
+	// type errors are aggregated with validation errors, except those of the same field
+	DefaultTester.Put(t, RequestConfig{
+		Route:          "/floors/abc?size=-1",
+		RequestBody:    Map{"content": ""},
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.Len(t, *httpError.Detail, 3)
+	assert.EqualValues(t, "id类型不正确", (*httpError.Detail)[0].Message)
+	assert.EqualValues(t, "content", (*httpError.Detail)[1].Field)
+	assert.EqualValues(t, "size", (*httpError.Detail)[2].Field)
+
+	// validation errors of all sources are aggregated
+	DefaultTester.Put(t, RequestConfig{
+		Route:          "/floors/0",
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.Len(t, *httpError.Detail, 2)
+}
@@ -0,0 +1,405 @@
+package common
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/BurntSushi/toml"
+	"github.com/creasty/defaults"
+	"github.com/goccy/go-json"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigOptions is the config of LoadConfig
+type ConfigOptions struct {
+	// File is the path of config file, the format is detected by extension:
+	// .yaml, .yml, .json and .toml are supported. Optional.
+	File string
+
+	// EnvPrefix is the prefix of env vars, e.g. "APP" reads APP_DATABASE_HOST into Database.Host.
+	// If empty, DATABASE_HOST is read.
+	EnvPrefix string
+
+	// Args are the command line flags, e.g. os.Args[1:], parsed as -database.host=localhost.
+	// If nil, flags are not parsed.
+	Args []string
+}
+
+// LoadConfig loads config into T from these sources, latter overrides former:
+//  1. `default` tags
+//  2. config file
+//  3. env vars
+//  4. command line flags
+//
+// Field names are taken from json tag, or snake_case of the field name if absent.
+// Nested struct names are joined with "." in files and flags, with "_" in env vars.
+// Use `env:"NAME"` tag to specify the full env var name of a field.
+// Pointers to nested structs are optional sections, they are left nil unless any of their fields is set.
+//
+// All errors of parsing and validating are aggregated into one *ErrorDetail.
+func LoadConfig[T any](options ConfigOptions) (*T, error) {
+	var config T
+
+	err := defaults.Set(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	var errorDetail ErrorDetail
+
+	if options.File != "" {
+		data, err := readConfigFile(options.File)
+		if err != nil {
+			return nil, err
+		}
+		fileErrors, _ := applyConfigMap(reflect.ValueOf(&config).Elem(), data, nil)
+		errorDetail = append(errorDetail, fileErrors...)
+	}
+
+	errorDetail = append(errorDetail, applyConfigEnv(&config, options.EnvPrefix)...)
+
+	if options.Args != nil {
+		flagErrors, err := applyConfigFlags(&config, options.Args)
+		if err != nil {
+			return nil, err
+		}
+		errorDetail = append(errorDetail, flagErrors...)
+	}
+
+	if err = ValidateStruct(&config); err != nil {
+		errorDetail = append(errorDetail, *err.(*ErrorDetail)...)
+	}
+
+	if len(errorDetail) > 0 {
+		return nil, &errorDetail
+	}
+
+	return &config, nil
+}
+
+func readConfigFile(filename string) (map[string]any, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &data)
+	case ".json":
+		err = json.Unmarshal(content, &data)
+	case ".toml":
+		err = toml.Unmarshal(content, &data)
+	default:
+		return nil, fmt.Errorf("unsupported config file format: %s", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", filename, err)
+	}
+
+	return data, nil
+}
+
+// configFieldName returns the name of a config field, or empty string if ignored
+func configFieldName(field reflect.StructField) string {
+	if !field.IsExported() || field.Tag.Get("json") == "-" {
+		return ""
+	}
+	if name := jsonTagName(field); name != "" {
+		return name
+	}
+	return toSnakeCase(field.Name)
+}
+
+// isConfigStruct reports whether the type should be walked into as nested config
+func isConfigStruct(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && !reflect.PointerTo(typ).Implements(textUnmarshalerType)
+}
+
+// walkConfigFields calls fn on every leaf field of the config struct type. The leaf is got by field, which
+// allocates nil pointers of optional sections on the way, so that sections without any value are left nil.
+func walkConfigFields(typ reflect.Type, parent func() reflect.Value, path []string, fn func(path []string, field func() reflect.Value, structField reflect.StructField)) {
+	for i := 0; i < typ.NumField(); i++ {
+		i, structField := i, typ.Field(i)
+		field := func() reflect.Value { return parent().Field(i) }
+
+		if structField.Anonymous && isConfigStruct(structField.Type) && jsonTagName(structField) == "" {
+			walkConfigFields(structField.Type, field, path, fn)
+			continue
+		}
+
+		name := configFieldName(structField)
+		if name == "" {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], name)
+
+		fieldType := structField.Type
+		if fieldType.Kind() == reflect.Pointer && isConfigStruct(fieldType.Elem()) {
+			pointer := field
+			field = func() reflect.Value {
+				value := pointer()
+				if value.IsNil() {
+					value.Set(newConfigSection(value.Type().Elem()))
+				}
+				return value.Elem()
+			}
+			fieldType = fieldType.Elem()
+		}
+		if isConfigStruct(fieldType) {
+			walkConfigFields(fieldType, field, fieldPath, fn)
+			continue
+		}
+
+		fn(fieldPath, field, structField)
+	}
+}
+
+// walkConfig calls fn on every leaf field of config, a pointer to struct
+func walkConfig(config any, fn func(path []string, field func() reflect.Value, structField reflect.StructField)) {
+	value := reflect.ValueOf(config).Elem()
+	walkConfigFields(value.Type(), func() reflect.Value { return value }, nil, fn)
+}
+
+// newConfigSection returns a pointer to a new section of typ with `default` tags set
+func newConfigSection(typ reflect.Type) reflect.Value {
+	section := reflect.New(typ)
+	_ = defaults.Set(section.Interface())
+	return section
+}
+
+func configError(path []string, source string, value any) *ErrorDetailElement {
+	name := strings.Join(path, ".")
+	return &ErrorDetailElement{
+		Tag:         "config",
+		Field:       name,
+		StructField: name,
+		Param:       source,
+		Value:       value,
+	}
+}
+
+// applyConfigMap sets the config struct from a map decoded from config file, found reports whether any field is set
+func applyConfigMap(value reflect.Value, data map[string]any, path []string) (errorDetail ErrorDetail, found bool) {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		structField := typ.Field(i)
+		field := value.Field(i)
+
+		if structField.Anonymous && isConfigStruct(structField.Type) && jsonTagName(structField) == "" {
+			embeddedErrors, embeddedFound := applyConfigMap(field, data, path)
+			errorDetail, found = append(errorDetail, embeddedErrors...), found || embeddedFound
+			continue
+		}
+
+		name := configFieldName(structField)
+		if name == "" {
+			continue
+		}
+		raw, ok := data[name]
+		if !ok {
+			continue
+		}
+		fieldPath := append(path[:len(path):len(path)], name)
+
+		if nested, ok := raw.(map[string]any); ok {
+			// optional sections are only allocated if any of their fields is set
+			if structField.Type.Kind() == reflect.Pointer && isConfigStruct(structField.Type.Elem()) {
+				section := field
+				if field.IsNil() {
+					section = newConfigSection(structField.Type.Elem())
+				}
+				nestedErrors, nestedFound := applyConfigMap(section.Elem(), nested, fieldPath)
+				if nestedFound {
+					field.Set(section)
+				}
+				errorDetail, found = append(errorDetail, nestedErrors...), found || nestedFound
+				continue
+			}
+			if isConfigStruct(field.Type()) {
+				nestedErrors, nestedFound := applyConfigMap(field, nested, fieldPath)
+				errorDetail, found = append(errorDetail, nestedErrors...), found || nestedFound
+				continue
+			}
+		}
+
+		found = true
+
+		var err error
+		if s, ok := raw.(string); ok {
+			err = setValueFromString(field, s)
+		} else {
+			var bytes []byte
+			bytes, err = json.Marshal(raw)
+			if err == nil {
+				err = json.Unmarshal(bytes, field.Addr().Interface())
+			}
+		}
+		if err != nil {
+			errorDetail = append(errorDetail, configError(fieldPath, "file", raw))
+		}
+	}
+	return errorDetail, found
+}
+
+func applyConfigEnv(config any, prefix string) (errorDetail ErrorDetail) {
+	walkConfig(config, func(path []string, field func() reflect.Value, structField reflect.StructField) {
+		name := structField.Tag.Get("env")
+		if name == "" {
+			name = strings.ToUpper(strings.Join(path, "_"))
+			if prefix != "" {
+				name = prefix + "_" + name
+			}
+		}
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+
+		if err := setValueFromString(field(), value); err != nil {
+			errorDetail = append(errorDetail, configError(path, name, value))
+		}
+	})
+	return errorDetail
+}
+
+// configFlag is a flag.Value holding the raw string, so that all leaf types can be parsed uniformly
+type configFlag struct {
+	value  string
+	isBool bool
+}
+
+func (f *configFlag) String() string { return f.value }
+
+func (f *configFlag) Set(value string) error {
+	f.value = value
+	return nil
+}
+
+func (f *configFlag) IsBoolFlag() bool { return f.isBool }
+
+func applyConfigFlags(config any, args []string) (ErrorDetail, error) {
+	flagSet := flag.NewFlagSet("config", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+
+	fields := make(map[string]func() reflect.Value)
+	walkConfig(config, func(path []string, field func() reflect.Value, structField reflect.StructField) {
+		name := strings.Join(path, ".")
+		fields[name] = field
+		flagSet.Var(&configFlag{isBool: structField.Type.Kind() == reflect.Bool}, name, name)
+	})
+
+	if err := flagSet.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var errorDetail ErrorDetail
+	flagSet.Visit(func(f *flag.Flag) {
+		value := f.Value.String()
+		if err := setValueFromString(fields[f.Name](), value); err != nil {
+			errorDetail = append(errorDetail, configError(strings.Split(f.Name, "."), "-"+f.Name, value))
+		}
+	})
+	return errorDetail, nil
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// setValueFromString parses s into value according to its type.
+// Slices are split by comma.
+func setValueFromString(value reflect.Value, s string) error {
+	if value.CanAddr() && value.Addr().Type().Implements(textUnmarshalerType) {
+		return value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	if value.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(value.Type().Elem())
+		if err := setValueFromString(elem.Elem(), s); err != nil {
+			return err
+		}
+		value.Set(elem)
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	case reflect.Slice:
+		var parts []string
+		if s != "" {
+			parts = strings.Split(s, ",")
+		}
+		slice := reflect.MakeSlice(value.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setValueFromString(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type: %s", value.Type())
+	}
+	return nil
+}
+
+// toSnakeCase converts "HTTPPort" to "http_port"
+func toSnakeCase(s string) string {
+	runes := []rune(s)
+	var builder strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				builder.WriteByte('_')
+			}
+			builder.WriteRune(unicode.ToLower(r))
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
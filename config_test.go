@@ -0,0 +1,104 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	Mode     string `json:"mode" default:"dev" validate:"oneof=dev prod"`
+	Debug    bool
+	Timeout  time.Duration `default:"5s"`
+	Tags     []string      `json:"tags"`
+	Database struct {
+		Host string `json:"host" default:"localhost"`
+		Port int    `json:"port" default:"5432" validate:"min=1"`
+	} `json:"database"`
+	Secret string `env:"TEST_CONFIG_SECRET"`
+	TLS    *struct {
+		Cert string `json:"cert" validate:"required"`
+		Key  string `json:"key" validate:"required"`
+		Port int    `json:"port" default:"443"`
+	} `json:"tls"`
+}
+
+func TestLoadConfig(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(filename, []byte("mode: prod\ntimeout: 1m\ntags: [a, b]\ndatabase:\n  host: db\n  port: 3306\n"), 0644)
+	assert.Nil(t, err)
+
+	t.Setenv("APP_DATABASE_PORT", "3307")
+	t.Setenv("TEST_CONFIG_SECRET", "secret")
+
+	config, err := LoadConfig[testConfig](ConfigOptions{
+		File:      filename,
+		EnvPrefix: "APP",
+		Args:      []string{"-debug", "-database.host=127.0.0.1"},
+	})
+	assert.Nil(t, err)
+	assert.EqualValues(t, "prod", config.Mode)
+	assert.True(t, config.Debug)
+	assert.EqualValues(t, time.Minute, config.Timeout)
+	assert.EqualValues(t, []string{"a", "b"}, config.Tags)
+	assert.EqualValues(t, "127.0.0.1", config.Database.Host)
+	assert.EqualValues(t, 3307, config.Database.Port)
+	assert.EqualValues(t, "secret", config.Secret)
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	config, err := LoadConfig[testConfig](ConfigOptions{})
+	assert.Nil(t, err)
+	assert.EqualValues(t, "dev", config.Mode)
+	assert.EqualValues(t, 5*time.Second, config.Timeout)
+	assert.EqualValues(t, "localhost", config.Database.Host)
+	assert.EqualValues(t, 5432, config.Database.Port)
+	assert.Nil(t, config.TLS)
+}
+
+func TestLoadConfigSection(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(filename, []byte(`{"tls":{"cert":"cert.pem"}}`), 0644)
+	assert.Nil(t, err)
+	t.Setenv("TLS_KEY", "key.pem")
+
+	config, err := LoadConfig[testConfig](ConfigOptions{File: filename})
+	assert.Nil(t, err)
+	assert.EqualValues(t, "cert.pem", config.TLS.Cert)
+	assert.EqualValues(t, "key.pem", config.TLS.Key)
+	assert.EqualValues(t, 443, config.TLS.Port)
+
+	// a section is validated once any of its fields is set, here cert is missing
+	_, err = LoadConfig[testConfig](ConfigOptions{Args: []string{"-tls.port=8443"}})
+	assert.IsType(t, &ErrorDetail{}, err)
+	assert.Len(t, *err.(*ErrorDetail), 1)
+
+	// empty sections are left nil
+	err = os.WriteFile(filename, []byte(`{"tls":{}}`), 0644)
+	assert.Nil(t, err)
+	assert.Nil(t, os.Unsetenv("TLS_KEY"))
+	config, err = LoadConfig[testConfig](ConfigOptions{File: filename})
+	assert.Nil(t, err)
+	assert.Nil(t, config.TLS)
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.toml")
+	err := os.WriteFile(filename, []byte("mode = \"test\"\n[database]\nport = \"abc\"\n"), 0644)
+	assert.Nil(t, err)
+
+	t.Setenv("APP_TIMEOUT", "forever")
+
+	_, err = LoadConfig[testConfig](ConfigOptions{File: filename, EnvPrefix: "APP"})
+	assert.IsType(t, &ErrorDetail{}, err)
+	assert.Len(t, *err.(*ErrorDetail), 3)
+}
+
+func TestToSnakeCase(t *testing.T) {
+	assert.EqualValues(t, "http_port", toSnakeCase("HTTPPort"))
+	assert.EqualValues(t, "database_host", toSnakeCase("DatabaseHost"))
+	assert.EqualValues(t, "id", toSnakeCase("ID"))
+}
@@ -0,0 +1,166 @@
+package common
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// configReloadDelay merges the burst of file events produced by a single save
+const configReloadDelay = 100 * time.Millisecond
+
+// ConfigWatcher holds a config loaded by LoadConfig and reloads it
+// when the config file changes or SIGHUP is received
+type ConfigWatcher[T any] struct {
+	options   ConfigOptions
+	config    atomic.Pointer[T]
+	mutex     sync.Mutex // guards reloading and callbacks, not held while calling callbacks
+	callbacks []func(old, new T)
+	watcher   *fsnotify.Watcher
+	target    string // resolved config file, changed by symlink swaps
+	signals   chan os.Signal
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// WatchConfig loads the config and starts watching for changes.
+// Call Close to stop watching.
+func WatchConfig[T any](options ConfigOptions) (*ConfigWatcher[T], error) {
+	config, err := LoadConfig[T](options)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &ConfigWatcher[T]{
+		options: options,
+		signals: make(chan os.Signal, 1),
+		done:    make(chan struct{}),
+	}
+	w.config.Store(config)
+
+	if options.File != "" {
+		w.target = resolveConfigFile(options.File)
+		w.watcher, err = fsnotify.NewWatcher()
+		if err != nil {
+			return nil, err
+		}
+		// watch the directory instead of the file, because editors and
+		// kubernetes configmaps replace the file rather than write to it
+		err = w.watcher.Add(filepath.Dir(options.File))
+		if err != nil {
+			_ = w.watcher.Close()
+			return nil, err
+		}
+	}
+
+	signal.Notify(w.signals, syscall.SIGHUP)
+	go w.run()
+
+	return w, nil
+}
+
+// Get returns the current config, it should not be modified
+func (w *ConfigWatcher[T]) Get() *T {
+	return w.config.Load()
+}
+
+// Watch registers a callback called after the config is reloaded successfully
+func (w *ConfigWatcher[T]) Watch(callback func(old, new T)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.callbacks = append(w.callbacks, callback)
+}
+
+// Reload loads and validates the config, then swaps it in.
+// If failed, the previous config is kept.
+func (w *ConfigWatcher[T]) Reload() error {
+	w.mutex.Lock()
+	config, err := LoadConfig[T](w.options)
+	if err != nil {
+		w.mutex.Unlock()
+		log.Err(err).Str("file", w.options.File).Msg("reload config error, keep previous config")
+		return err
+	}
+
+	old := w.config.Swap(config)
+	callbacks := append([]func(old, new T){}, w.callbacks...)
+	w.mutex.Unlock()
+	log.Info().Str("file", w.options.File).Msg("config reloaded")
+
+	// callbacks are called without the lock, so that they can call Reload or Watch
+	for _, callback := range callbacks {
+		callback(*old, *config)
+	}
+	return nil
+}
+
+// Close stops watching
+func (w *ConfigWatcher[T]) Close() (err error) {
+	w.closeOnce.Do(func() {
+		signal.Stop(w.signals)
+		close(w.done)
+		if w.watcher != nil {
+			err = w.watcher.Close()
+		}
+	})
+	return err
+}
+
+func (w *ConfigWatcher[T]) run() {
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if w.watcher != nil {
+		events = w.watcher.Events
+		errs = w.watcher.Errors
+	}
+
+	filename := filepath.Clean(w.options.File)
+	timer := time.NewTimer(0)
+	<-timer.C
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.signals:
+			_ = w.Reload()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			// kubernetes configmaps swap the ..data symlink in the directory,
+			// which changes the resolved target without any event of the file itself
+			if filepath.Clean(event.Name) == filename && event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+				timer.Reset(configReloadDelay)
+			} else if target := resolveConfigFile(filename); target != w.target {
+				w.target = target
+				timer.Reset(configReloadDelay)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+			log.Err(err).Str("file", w.options.File).Msg("watch config error")
+		case <-timer.C:
+			_ = w.Reload()
+		}
+	}
+}
+
+// resolveConfigFile returns the real path of the config file following symlinks,
+// or an empty string if it does not exist
+func resolveConfigFile(filename string) string {
+	resolved, err := filepath.EvalSymlinks(filename)
+	if err != nil {
+		return ""
+	}
+	return resolved
+}
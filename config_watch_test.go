@@ -0,0 +1,113 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchConfig(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(filename, []byte(`{"mode": "dev"}`), 0644)
+	assert.Nil(t, err)
+
+	watcher, err := WatchConfig[testConfig](ConfigOptions{File: filename})
+	assert.Nil(t, err)
+	defer func() { _ = watcher.Close() }()
+	assert.EqualValues(t, "dev", watcher.Get().Mode)
+
+	changed := make(chan [2]string, 1)
+	watcher.Watch(func(old, new testConfig) {
+		changed <- [2]string{old.Mode, new.Mode}
+	})
+
+	// file change
+	err = os.WriteFile(filename, []byte(`{"mode": "prod"}`), 0644)
+	assert.Nil(t, err)
+	select {
+	case modes := <-changed:
+		assert.EqualValues(t, [2]string{"dev", "prod"}, modes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config not reloaded")
+	}
+	assert.EqualValues(t, "prod", watcher.Get().Mode)
+
+	// invalid config is rejected
+	err = os.WriteFile(filename, []byte(`{"mode": "invalid"}`), 0644)
+	assert.Nil(t, err)
+	err = watcher.Reload()
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "prod", watcher.Get().Mode)
+}
+
+func TestWatchConfigMap(t *testing.T) {
+	// the layout of a mounted kubernetes configmap:
+	// config.json -> ..data/config.json, ..data -> ..2024_01_01
+	dir := t.TempDir()
+	writeVersion := func(version, mode string) {
+		err := os.Mkdir(filepath.Join(dir, version), 0755)
+		assert.Nil(t, err)
+		err = os.WriteFile(filepath.Join(dir, version, "config.json"), []byte(`{"mode": "`+mode+`"}`), 0644)
+		assert.Nil(t, err)
+		err = os.Symlink(version, filepath.Join(dir, "..data_tmp"))
+		assert.Nil(t, err)
+		err = os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data"))
+		assert.Nil(t, err)
+	}
+	writeVersion("..2024_01_01", "dev")
+	err := os.Symlink(filepath.Join("..data", "config.json"), filepath.Join(dir, "config.json"))
+	assert.Nil(t, err)
+
+	watcher, err := WatchConfig[testConfig](ConfigOptions{File: filepath.Join(dir, "config.json")})
+	assert.Nil(t, err)
+	defer func() { _ = watcher.Close() }()
+	assert.EqualValues(t, "dev", watcher.Get().Mode)
+
+	changed := make(chan string, 1)
+	watcher.Watch(func(_, new testConfig) {
+		changed <- new.Mode
+	})
+
+	writeVersion("..2024_01_02", "prod")
+	select {
+	case mode := <-changed:
+		assert.EqualValues(t, "prod", mode)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config not reloaded")
+	}
+}
+
+func TestConfigWatcherCallbackReload(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(filename, []byte(`{"mode": "dev"}`), 0644)
+	assert.Nil(t, err)
+
+	watcher, err := WatchConfig[testConfig](ConfigOptions{File: filename})
+	assert.Nil(t, err)
+	defer func() { _ = watcher.Close() }()
+
+	// a callback calling Reload or Watch should not deadlock
+	calls := 0
+	watcher.Watch(func(_, _ testConfig) {
+		calls++
+		if calls == 1 {
+			watcher.Watch(func(_, _ testConfig) {})
+			assert.Nil(t, watcher.Reload())
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		assert.Nil(t, watcher.Reload())
+		close(done)
+	}()
+	select {
+	case <-done:
+		assert.EqualValues(t, 2, calls)
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload deadlocked")
+	}
+}
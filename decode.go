@@ -0,0 +1,244 @@
+package common
+
+import (
+	"bytes"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const MIMEApplicationProtobufJSON = "application/protobuf+json"
+
+// BodyDecoder decodes request body into model
+type BodyDecoder func(body []byte, model any) error
+
+var (
+	bodyDecoders = map[string]BodyDecoder{
+		MIMEApplicationMsgPack:      unmarshalMsgPack,
+		MIMEApplicationXMsgPack:     unmarshalMsgPack,
+		MIMEApplicationCBOR:         cbor.Unmarshal,
+		MIMEApplicationProtobufJSON: unmarshalProtoJSON,
+	}
+	bodyDecodersMutex sync.RWMutex
+)
+
+// RegisterBodyDecoder registers a decoder used by ValidateBody for the content type,
+// it takes precedence over the built-in json, xml and form parsers of fiber
+func RegisterBodyDecoder(contentType string, decoder BodyDecoder) {
+	bodyDecodersMutex.Lock()
+	defer bodyDecodersMutex.Unlock()
+	bodyDecoders[strings.ToLower(contentType)] = decoder
+}
+
+func getBodyDecoder(contentType string) (BodyDecoder, bool) {
+	bodyDecodersMutex.RLock()
+	defer bodyDecodersMutex.RUnlock()
+	decoder, ok := bodyDecoders[contentType]
+	return decoder, ok
+}
+
+// unmarshalMsgPack decodes with json tags, so that field names are the same as json
+func unmarshalMsgPack(body []byte, model any) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(body))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(model)
+}
+
+// unmarshalProtoJSON decodes protobuf messages by protojson, other models by json
+func unmarshalProtoJSON(body []byte, model any) error {
+	if message, ok := model.(proto.Message); ok {
+		return protojson.Unmarshal(body, message)
+	}
+	return json.Unmarshal(body, model)
+}
+
+// mediaType returns the lowercase content type without parameters
+func mediaType(c *fiber.Ctx) string {
+	contentType := strings.ToLower(string(c.Request().Header.ContentType()))
+	contentType, _, _ = strings.Cut(contentType, ";")
+	return strings.TrimSpace(contentType)
+}
+
+// parseBody decodes body by registered decoders or fiber.BodyParser,
+// and binds uploaded files of multipart form
+func parseBody(c *fiber.Ctx, model any) error {
+	contentType := mediaType(c)
+	if decoder, ok := getBodyDecoder(contentType); ok {
+		if err := decoder(c.Body(), model); err != nil {
+			return BadRequest(err.Error())
+		}
+		return nil
+	}
+
+	if err := c.BodyParser(model); err != nil {
+		return BadRequest(err.Error())
+	}
+
+	if contentType == fiber.MIMEMultipartForm {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return BadRequest(err.Error())
+		}
+		bindFiles(model, form.File)
+	}
+	return nil
+}
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// bindFiles sets *multipart.FileHeader and []*multipart.FileHeader fields by form tag
+func bindFiles(model any, files map[string][]*multipart.FileHeader) {
+	value, ok := structValue(model)
+	if !ok {
+		return
+	}
+	for _, field := range reflect.VisibleFields(value.Type()) {
+		if !field.IsExported() || (field.Type != fileHeaderType && field.Type != fileHeaderSliceType) {
+			continue
+		}
+		headers := files[formName(field)]
+		if len(headers) == 0 {
+			continue
+		}
+		if field.Type == fileHeaderType {
+			value.FieldByIndex(field.Index).Set(reflect.ValueOf(headers[0]))
+		} else {
+			value.FieldByIndex(field.Index).Set(reflect.ValueOf(headers))
+		}
+	}
+}
+
+func formName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name
+}
+
+// parseSize parses "512", "64KB", "10MB" or "1GB" into bytes
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+	for suffix, size := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if strings.HasSuffix(s, suffix) {
+			s, unit = strings.TrimSuffix(s, suffix), size
+			break
+		}
+	}
+	size, err := strconv.ParseInt(strings.TrimSuffix(s, "B"), 10, 64)
+	return size * unit, err
+}
+
+// detectMIME detects the mime type of the uploaded file by its content
+func detectMIME(header *multipart.FileHeader) (*mimetype.MIME, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return mimetype.DetectReader(file)
+}
+
+// matchMIME reports whether detected mime type or its parents matches one of allowed,
+// allowed can be "image/png" or "image/*"
+func matchMIME(detected *mimetype.MIME, allowed []string) bool {
+	for mime := detected; mime != nil; mime = mime.Parent() {
+		for _, expected := range allowed {
+			if prefix, found := strings.CutSuffix(expected, "/*"); found {
+				if strings.HasPrefix(mime.String(), prefix+"/") {
+					return true
+				}
+			} else if mime.Is(expected) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateFiles validates uploaded files by `file` tag, e.g.
+//
+//	Avatar *multipart.FileHeader `form:"avatar" file:"max_size=2MB,mime=image/png|image/jpeg"`
+func validateFiles(model any) (errorDetail ErrorDetail, err error) {
+	value, ok := structValue(model)
+	if !ok {
+		return nil, nil
+	}
+
+	for _, field := range reflect.VisibleFields(value.Type()) {
+		tag, ok := field.Tag.Lookup("file")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		var headers []*multipart.FileHeader
+		switch fieldValue := value.FieldByIndex(field.Index).Interface().(type) {
+		case *multipart.FileHeader:
+			if fieldValue != nil {
+				headers = append(headers, fieldValue)
+			}
+		case []*multipart.FileHeader:
+			headers = fieldValue
+		}
+
+		name := formName(field)
+		for _, rule := range strings.Split(tag, ",") {
+			key, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+			for _, header := range headers {
+				switch key {
+				case "max_size":
+					maxSize, err := parseSize(param)
+					if err != nil {
+						return nil, err
+					}
+					if header.Size > maxSize {
+						errorDetail = append(errorDetail, &ErrorDetailElement{
+							Tag:         key,
+							Field:       name,
+							Kind:        reflect.Int64,
+							Value:       header.Size,
+							Param:       param,
+							StructField: field.Name,
+							Label:       fieldLabel(field),
+							Path:        name,
+							Pointer:     jsonPointer(name),
+						})
+					}
+				case "mime":
+					detected, err := detectMIME(header)
+					if err != nil {
+						return nil, err
+					}
+					if !matchMIME(detected, strings.Split(param, "|")) {
+						errorDetail = append(errorDetail, &ErrorDetailElement{
+							Tag:         key,
+							Field:       name,
+							Kind:        reflect.String,
+							Value:       detected.String(),
+							Param:       strings.ReplaceAll(param, "|", " "),
+							StructField: field.Name,
+							Label:       fieldLabel(field),
+							Path:        name,
+							Pointer:     jsonPointer(name),
+						})
+					}
+				}
+			}
+		}
+	}
+	return errorDetail, nil
+}
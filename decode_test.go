@@ -0,0 +1,128 @@
+package common
+
+import (
+	"bytes"
+	"mime/multipart"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestValidateBodyDecoders(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/floors", func(c *fiber.Ctx) error {
+		var body struct {
+			Content string `json:"content" validate:"required"`
+			Likes   int    `json:"likes" default:"1"`
+		}
+		if err := ValidateBody(c, &body); err != nil {
+			return err
+		}
+		return c.Status(201).JSON(body)
+	})
+	RegisterApp(app)
+
+	data, err := msgpack.Marshal(map[string]any{"content": "a"})
+	assert.Nil(t, err)
+	DefaultTester.Post(t, RequestConfig{
+		Route:        "/floors",
+		RequestBody:  string(data),
+		ContentType:  MIMEApplicationMsgPack,
+		ExpectedBody: `{"content":"a","likes":1}`,
+	})
+
+	data, err = cbor.Marshal(map[string]any{"content": "b", "likes": 2})
+	assert.Nil(t, err)
+	DefaultTester.Post(t, RequestConfig{
+		Route:        "/floors",
+		RequestBody:  string(data),
+		ContentType:  MIMEApplicationCBOR,
+		ExpectedBody: `{"content":"b","likes":2}`,
+	})
+
+	data, err = cbor.Marshal(map[string]any{"likes": 2})
+	assert.Nil(t, err)
+	DefaultTester.Post(t, RequestConfig{
+		Route:          "/floors",
+		RequestBody:    string(data),
+		ContentType:    MIMEApplicationCBOR,
+		ExpectedStatus: 400,
+	})
+
+	RegisterBodyDecoder("application/x-test", func(body []byte, model any) error {
+		return msgpack.Unmarshal([]byte{0x80}, model)
+	})
+	DefaultTester.Post(t, RequestConfig{
+		Route:          "/floors",
+		RequestBody:    "plain",
+		ContentType:    "application/x-test; charset=utf-8",
+		ExpectedStatus: 400,
+	})
+}
+
+func TestValidateBodyFiles(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/images", func(c *fiber.Ctx) error {
+		var body struct {
+			Name   string                  `form:"name" json:"name"`
+			Image  *multipart.FileHeader   `form:"image" file:"max_size=1KB,mime=image/*" validate:"required"`
+			Extras []*multipart.FileHeader `form:"extras" file:"mime=image/png|image/jpeg"`
+		}
+		if err := ValidateBody(c, &body); err != nil {
+			return err
+		}
+		return c.Status(201).SendString(body.Name + " " + body.Image.Filename)
+	})
+	RegisterApp(app)
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+	newForm := func(files map[string][]byte) (string, string) {
+		var buffer bytes.Buffer
+		writer := multipart.NewWriter(&buffer)
+		_ = writer.WriteField("name", "test")
+		for name, content := range files {
+			part, _ := writer.CreateFormFile(name, name+".bin")
+			_, _ = part.Write(content)
+		}
+		_ = writer.Close()
+		return buffer.String(), writer.FormDataContentType()
+	}
+
+	body, contentType := newForm(map[string][]byte{"image": png, "extras": png})
+	DefaultTester.Post(t, RequestConfig{
+		Route:        "/images",
+		RequestBody:  body,
+		ContentType:  contentType,
+		ExpectedBody: "test image.bin",
+	})
+
+	var httpError HttpError
+	body, contentType = newForm(map[string][]byte{"image": bytes.Repeat([]byte("a"), 2048), "extras": []byte("text")})
+	DefaultTester.Post(t, RequestConfig{
+		Route:          "/images",
+		RequestBody:    body,
+		ContentType:    contentType,
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.Len(t, *httpError.Detail, 3)
+
+	body, contentType = newForm(nil)
+	DefaultTester.Post(t, RequestConfig{
+		Route:          "/images",
+		RequestBody:    body,
+		ContentType:    contentType,
+		ExpectedStatus: 400,
+	})
+}
+
+func TestParseSize(t *testing.T) {
+	for s, expected := range map[string]int64{"512": 512, "64KB": 64 << 10, "10mb": 10 << 20, "1GB": 1 << 30} {
+		size, err := parseSize(s)
+		assert.Nil(t, err)
+		assert.EqualValues(t, expected, size)
+	}
+}
@@ -62,6 +62,28 @@ func NotFound(messages ...string) *HttpError {
 	}
 }
 
+func NotAcceptable(messages ...string) *HttpError {
+	message := "Not Acceptable"
+	if len(messages) > 0 {
+		message = messages[0]
+	}
+	return &HttpError{
+		Code:    406,
+		Message: message,
+	}
+}
+
+func Conflict(messages ...string) *HttpError {
+	message := "Conflict"
+	if len(messages) > 0 {
+		message = messages[0]
+	}
+	return &HttpError{
+		Code:    409,
+		Message: message,
+	}
+}
+
 func InternalServerError(messages ...string) *HttpError {
 	message := "Internal Server Error"
 	if len(messages) > 0 {
@@ -73,6 +95,17 @@ func InternalServerError(messages ...string) *HttpError {
 	}
 }
 
+func ServiceUnavailable(messages ...string) *HttpError {
+	message := "Service Unavailable"
+	if len(messages) > 0 {
+		message = messages[0]
+	}
+	return &HttpError{
+		Code:    503,
+		Message: message,
+	}
+}
+
 func ErrorHandler(ctx *fiber.Ctx, err error) error {
 	if err == nil {
 		return nil
@@ -89,11 +122,22 @@ func ErrorHandler(ctx *fiber.Ctx, err error) error {
 		switch e := err.(type) {
 		case *HttpError:
 			httpError = *e
+			if e.Detail != nil {
+				// the message derived from detail is translated too, custom messages are kept
+				detail := e.Detail.Translate(Language(ctx))
+				if e.Message == "" || e.Message == e.Detail.Error() {
+					httpError.Message = detail.Error()
+				}
+				httpError.Detail = &detail
+			}
 		case *fiber.Error:
 			httpError.Code = e.Code
 		case *ErrorDetail:
+			// messages in the language of Accept-Language
+			detail := e.Translate(Language(ctx))
 			httpError.Code = 400
-			httpError.Detail = e
+			httpError.Message = detail.Error()
+			httpError.Detail = &detail
 		case fiber.MultiError:
 			httpError.Code = 400
 			httpError.Message = ""
@@ -118,5 +162,10 @@ func ErrorHandler(ctx *fiber.Ctx, err error) error {
 		}
 	}
 
-	return ctx.Status(statusCode).JSON(&httpError)
+	// render in the format accepted by client, fallback to json
+	contentType := negotiate(ctx)
+	if contentType == "" {
+		contentType = fiber.MIMEApplicationJSON
+	}
+	return render(ctx, contentType, statusCode, &httpError)
 }
@@ -0,0 +1,95 @@
+package common
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// fieldPaths returns the JSON path like tags[2].name and JSON Pointer like /tags/2/name of a validation error,
+// from its struct namespace and namespace in json names. The leading type name is stripped, see fieldSegments,
+// and embedded structs without json names are skipped as their fields are promoted in json.
+// Slice indices are written in brackets and map keys as fields, or quoted in brackets if not identifiers.
+func fieldPaths(typ reflect.Type, structNamespace, namespace string) (path, pointer string) {
+	structSegments := fieldSegments(typ, structNamespace)
+	segments := fieldSegments(typ, namespace)
+	if len(segments) != len(structSegments) || len(segments) == 0 {
+		return "", ""
+	}
+
+	var pathBuilder, pointerBuilder strings.Builder
+	for i, segment := range segments {
+		for typ != nil && typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		embedded := false
+		if typ != nil && typ.Kind() == reflect.Struct {
+			if field, ok := typ.FieldByName(structSegments[i].name); ok {
+				typ = field.Type
+				embedded = field.Anonymous && jsonTagName(field) == "" && len(segment.indices) == 0
+			} else {
+				typ = nil
+			}
+		} else {
+			typ = nil
+		}
+
+		if !embedded {
+			if pathBuilder.Len() > 0 {
+				pathBuilder.WriteByte('.')
+			}
+			pathBuilder.WriteString(segment.name)
+			pointerBuilder.WriteString("/" + escapePointer(segment.name))
+		}
+
+		for _, index := range segment.indices {
+			for typ != nil && typ.Kind() == reflect.Pointer {
+				typ = typ.Elem()
+			}
+			isMap := typ != nil && typ.Kind() == reflect.Map
+			switch {
+			case !isMap:
+				pathBuilder.WriteString("[" + index + "]")
+			case identifierPattern.MatchString(index):
+				pathBuilder.WriteString("." + index)
+			default:
+				pathBuilder.WriteString("[" + strconv.Quote(index) + "]")
+			}
+			pointerBuilder.WriteString("/" + escapePointer(index))
+			if typ != nil && (isMap || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+				typ = typ.Elem()
+			} else {
+				typ = nil
+			}
+		}
+	}
+	return pathBuilder.String(), pointerBuilder.String()
+}
+
+// jsonPointer converts a JSON path like tags[1].name into JSON Pointer /tags/1/name
+func jsonPointer(path string) string {
+	if path == "" {
+		return ""
+	}
+	var builder strings.Builder
+	for _, segment := range splitNamespace(path) {
+		if segment.name != "" {
+			builder.WriteString("/" + escapePointer(segment.name))
+		}
+		for _, index := range segment.indices {
+			if unquoted, err := strconv.Unquote(index); err == nil {
+				index = unquoted
+			}
+			builder.WriteString("/" + escapePointer(index))
+		}
+	}
+	return builder.String()
+}
+
+// escapePointer escapes a reference token of JSON Pointer as RFC 6901
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
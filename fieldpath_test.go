@@ -0,0 +1,105 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPathRequest struct {
+	Tags []struct {
+		Name string `json:"name" validate:"required"`
+	} `json:"tags" validate:"dive"`
+	Options struct {
+		Color string `json:"color" validate:"omitempty,hexcolor"`
+	} `json:"options"`
+	Extra map[string]*struct {
+		Value int `json:"value" validate:"min=1"`
+	} `json:"extra" validate:"dive"`
+	Matrix [][]int `json:"matrix" validate:"dive,dive,min=1"`
+}
+
+func TestFieldPaths(t *testing.T) {
+	request := testPathRequest{}
+	request.Tags = append(request.Tags, struct {
+		Name string `json:"name" validate:"required"`
+	}{"a"}, struct {
+		Name string `json:"name" validate:"required"`
+	}{})
+	request.Options.Color = "red"
+	request.Extra = map[string]*struct {
+		Value int `json:"value" validate:"min=1"`
+	}{"a/b": {}}
+	request.Matrix = [][]int{{1, 0}}
+
+	err := ValidateStruct(&request)
+	assert.NotNil(t, err)
+	paths := make(map[string]string)
+	for _, element := range *err.(*ErrorDetail) {
+		paths[element.Path] = element.Pointer
+	}
+	assert.EqualValues(t, map[string]string{
+		"tags[1].name":       "/tags/1/name",
+		"options.color":      "/options/color",
+		`extra["a/b"].value`: "/extra/a~1b/value",
+		"matrix[0][1]":       "/matrix/0/1",
+	}, paths)
+}
+
+func TestJSONPointer(t *testing.T) {
+	for path, pointer := range map[string]string{
+		"":               "",
+		"content":        "/content",
+		"tags[1].colr":   "/tags/1/colr",
+		"extra.k.x":      "/extra/k/x",
+		`extra["a~b"].x`: "/extra/a~0b/x",
+	} {
+		assert.EqualValues(t, pointer, jsonPointer(path))
+	}
+}
+
+func TestFieldPathsAnonymous(t *testing.T) {
+	// anonymous struct models have no type name in namespace
+	var body struct {
+		Content string `json:"content" label:"内容" validate:"required"`
+		Tags    []struct {
+			Name string `json:"name" validate:"required"`
+		} `json:"tags" validate:"dive"`
+	}
+	body.Tags = make([]struct {
+		Name string `json:"name" validate:"required"`
+	}, 1)
+
+	err := ValidateStruct(&body)
+	assert.NotNil(t, err)
+	errorDetail := *err.(*ErrorDetail)
+	assert.Len(t, errorDetail, 2)
+	assert.EqualValues(t, "content", errorDetail[0].Path)
+	assert.EqualValues(t, "/content", errorDetail[0].Pointer)
+	assert.EqualValues(t, "内容", errorDetail[0].Label)
+	assert.EqualValues(t, "tags[0].name", errorDetail[1].Path)
+	assert.EqualValues(t, "/tags/0/name", errorDetail[1].Pointer)
+}
+
+func TestFieldPathsEmbedded(t *testing.T) {
+	// fields of embedded structs are promoted in json
+	type Request struct {
+		PageRequest
+		Options struct {
+			PageRequest
+		} `json:"options"`
+	}
+	request := Request{PageRequest: PageRequest{Offset: -1, Limit: 10, Size: 10}}
+	request.Options.PageRequest = PageRequest{Offset: -1, Limit: 10, Size: 10}
+
+	err := ValidateStruct(&request)
+	assert.NotNil(t, err)
+	paths := make(map[string]string)
+	for _, element := range *err.(*ErrorDetail) {
+		paths[element.Path] = element.Pointer
+	}
+	assert.EqualValues(t, map[string]string{
+		"offset":         "/offset",
+		"options.offset": "/options/offset",
+	}, paths)
+}
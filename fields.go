@@ -0,0 +1,224 @@
+package common
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+// fieldTree is the parsed fields expression, a nil subtree selects all nested fields
+type fieldTree map[string]fieldTree
+
+// parseFields parses "id,content,floors.id" into a fieldTree
+func parseFields(fields string) fieldTree {
+	tree := fieldTree{}
+	for _, path := range strings.Split(fields, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		node := tree
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			subtree, ok := node[name]
+			if i == len(names)-1 {
+				// the whole field is selected
+				node[name] = nil
+				break
+			}
+			if ok && subtree == nil {
+				// already selected as a whole
+				break
+			}
+			if !ok {
+				subtree = fieldTree{}
+				node[name] = subtree
+			}
+			node = subtree
+		}
+	}
+	return tree
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// jsonFields returns the json names of a struct type, following embedded structs
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+		name := jsonTagName(field)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			for embeddedName, embeddedType := range jsonFields(fieldType) {
+				if _, ok := fields[embeddedName]; !ok {
+					fields[embeddedName] = embeddedType
+				}
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// checkFields reports the fields in tree not existing in typ
+func checkFields(typ reflect.Type, tree fieldTree, prefix string) (errorDetail ErrorDetail) {
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Interface || typ.Kind() == reflect.Map {
+		// unable to check dynamic fields
+		return nil
+	}
+
+	var fields map[string]reflect.Type
+	if typ.Kind() == reflect.Struct && !typ.Implements(jsonMarshalerType) && !reflect.PointerTo(typ).Implements(jsonMarshalerType) {
+		fields = jsonFields(typ)
+	}
+
+	// in order of names, so that errors are stable
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		subtree := tree[name]
+		fieldType, ok := fields[name]
+		if !ok {
+			errorDetail = append(errorDetail, &ErrorDetailElement{
+				Tag:         "fields",
+				Field:       prefix + name,
+				Kind:        reflect.String,
+				Value:       prefix + name,
+				StructField: prefix + name,
+			})
+			continue
+		}
+		if subtree != nil {
+			errorDetail = append(errorDetail, checkFields(fieldType, subtree, prefix+name+".")...)
+		}
+	}
+	return errorDetail
+}
+
+// pruneFields keeps only the selected fields in raw json
+func pruneFields(raw json.RawMessage, tree fieldTree) (json.RawMessage, error) {
+	if tree == nil {
+		return raw, nil
+	}
+
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return raw, nil
+	}
+
+	switch trimmed[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, err
+		}
+		for i := range items {
+			pruned, err := pruneFields(items[i], tree)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = pruned
+		}
+		return json.Marshal(items)
+	case '{':
+		// decode the object in order, so that kept fields are in the original order
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		var buffer bytes.Buffer
+		buffer.WriteByte('{')
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			name, _ := token.(string)
+			var value json.RawMessage
+			if err = decoder.Decode(&value); err != nil {
+				return nil, err
+			}
+			subtree, ok := tree[name]
+			if !ok {
+				continue
+			}
+			if value, err = pruneFields(value, subtree); err != nil {
+				return nil, err
+			}
+			if buffer.Len() > 1 {
+				buffer.WriteByte(',')
+			}
+			key, _ := json.Marshal(name)
+			buffer.Write(key)
+			buffer.WriteByte(':')
+			buffer.Write(value)
+		}
+		buffer.WriteByte('}')
+		return buffer.Bytes(), nil
+	default:
+		return raw, nil
+	}
+}
+
+// SelectFields marshals v into json with only the selected fields.
+// fields is a comma separated list of json paths, e.g. "id,content,floors.id".
+// Empty fields selects all fields.
+// Unknown fields are reported as *ErrorDetail.
+func SelectFields(v any, fields string) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := parseFields(fields)
+	if len(tree) == 0 {
+		return data, nil
+	}
+
+	if v != nil {
+		if errorDetail := checkFields(reflect.TypeOf(v), tree, ""); len(errorDetail) > 0 {
+			return nil, &errorDetail
+		}
+	}
+
+	return pruneFields(data, tree)
+}
+
+// SendFields sends v in json with only the fields selected by the fields expression,
+// usually from query parameter: SendFields(c, hole, c.Query("fields"))
+func SendFields(c *fiber.Ctx, v any, fields string) error {
+	data, err := SelectFields(v, fields)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(data)
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testFloor struct {
+	ID      int    `json:"id"`
+	Content string `json:"content"`
+}
+
+type testHoleWithFloors struct {
+	testHole
+	Floors []*testFloor `json:"floors"`
+	Hidden bool         `json:"-"`
+}
+
+func TestSelectFields(t *testing.T) {
+	hole := testHoleWithFloors{
+		testHole: testHole{ID: 1, Content: "hole", Likes: 2},
+		Floors:   []*testFloor{{ID: 1, Content: "a"}, {ID: 2, Content: "b"}},
+	}
+
+	data, err := SelectFields(hole, "id,content,floors.id")
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"id":1,"content":"hole","floors":[{"id":1},{"id":2}]}`, string(data))
+
+	// fields are kept in the order of struct
+	data, err = SelectFields(hole, "floors,content,id")
+	assert.Nil(t, err)
+	assert.EqualValues(t, `{"id":1,"content":"hole","floors":[{"id":1,"content":"a"},{"id":2,"content":"b"}]}`, string(data))
+
+	data, err = SelectFields(&hole, "floors.id,floors")
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"floors":[{"id":1,"content":"a"},{"id":2,"content":"b"}]}`, string(data))
+
+	data, err = SelectFields([]testHoleWithFloors{hole}, "likes")
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[{"likes":2}]`, string(data))
+
+	data, err = SelectFields(hole, "")
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"division_id":0`)
+
+	_, err = SelectFields(hole, "id,Hidden,floors.likes,likes.value")
+	assert.IsType(t, &ErrorDetail{}, err)
+	assert.Len(t, *err.(*ErrorDetail), 3)
+
+	// errors are in order of names
+	for i := 0; i < 10; i++ {
+		_, err = SelectFields(hole, "z,b,a,y")
+		assert.EqualValues(t, "字段a不存在, 字段b不存在, 字段y不存在, 字段z不存在", err.Error())
+	}
+}
+
+func TestSendFields(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/floors", func(c *fiber.Ctx) error {
+		return SendFields(c, testFloor{ID: 1, Content: "a"}, c.Query("fields"))
+	})
+	RegisterApp(app)
+
+	DefaultTester.Get(t, RequestConfig{Route: "/floors?fields=id", ExpectedBody: `{"id":1}`})
+	DefaultTester.Get(t, RequestConfig{Route: "/floors?fields=likes", ExpectedStatus: 400})
+}
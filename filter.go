@@ -0,0 +1,310 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// filter operators supported in `filter` tag
+const (
+	FilterEq      = "eq"
+	FilterNe      = "ne"
+	FilterIn      = "in"
+	FilterGt      = "gt"
+	FilterGte     = "gte"
+	FilterLt      = "lt"
+	FilterLte     = "lte"
+	FilterLike    = "like"
+	FilterBetween = "between"
+)
+
+type filterField struct {
+	index  []int
+	name   string // json name, used in ErrorDetail
+	column clause.Column
+	op     string
+}
+
+type sortField struct {
+	index   []int
+	name    string
+	allowed map[string]bool
+	param   string
+}
+
+type filterSpec struct {
+	filters []filterField
+	sorts   []sortField
+}
+
+var filterSpecs sync.Map // map[reflect.Type]*filterSpec
+
+// getFilterSpec parses and caches the filter spec of a query struct type.
+//
+// A filterable field is tagged with `filter:"column,op"` or `filter:"op"`,
+// where column defaults to the field's json name.
+// A sortable field is a string tagged with `sort:"column1,column2"` as the whitelist.
+// It panics on invalid tags, as they are programming errors.
+func getFilterSpec(typ reflect.Type) *filterSpec {
+	if spec, ok := filterSpecs.Load(typ); ok {
+		return spec.(*filterSpec)
+	}
+
+	spec := &filterSpec{}
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name := jsonTagName(field)
+		if name == "" {
+			name = toSnakeCase(field.Name)
+		}
+
+		if tag, ok := field.Tag.Lookup("filter"); ok {
+			column, op, found := strings.Cut(tag, ",")
+			if !found {
+				column, op = name, column
+			}
+			switch op {
+			case FilterEq, FilterNe, FilterIn, FilterGt, FilterGte, FilterLt, FilterLte, FilterLike, FilterBetween:
+			default:
+				panic(fmt.Sprintf("filter: unsupported operator %q of %s.%s", op, typ, field.Name))
+			}
+			spec.filters = append(spec.filters, filterField{
+				index:  field.Index,
+				name:   name,
+				column: filterColumn(column),
+				op:     op,
+			})
+		}
+
+		if tag, ok := field.Tag.Lookup("sort"); ok {
+			if field.Type.Kind() != reflect.String {
+				panic(fmt.Sprintf("sort: %s.%s should be string", typ, field.Name))
+			}
+			allowed := make(map[string]bool)
+			for _, column := range strings.Split(tag, ",") {
+				allowed[strings.TrimSpace(column)] = true
+			}
+			spec.sorts = append(spec.sorts, sortField{
+				index:   field.Index,
+				name:    name,
+				allowed: allowed,
+				param:   strings.ReplaceAll(tag, ",", " "),
+			})
+		}
+	}
+
+	actual, _ := filterSpecs.LoadOrStore(typ, spec)
+	return actual.(*filterSpec)
+}
+
+// filterColumn parses "column" or "table.column"
+func filterColumn(name string) clause.Column {
+	if table, column, found := strings.Cut(name, "."); found {
+		return clause.Column{Table: table, Name: column}
+	}
+	return clause.Column{Table: clause.CurrentTable, Name: name}
+}
+
+func structValue(query any) (reflect.Value, bool) {
+	value := reflect.ValueOf(query)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return value, false
+		}
+		value = value.Elem()
+	}
+	return value, value.Kind() == reflect.Struct
+}
+
+// filterValue returns the dereferenced field value, or false if not provided.
+// A non-nil pointer or a present Optional is provided even if it is zero value.
+func filterValue(value reflect.Value) (reflect.Value, bool) {
+	if _, ok := optionalValueType(value.Type()); ok {
+		if value, ok = optionalValue(value); !ok {
+			return value, false
+		}
+		if value.Kind() == reflect.Slice {
+			return value, value.Len() > 0
+		}
+		return value, true
+	}
+	isPointer := value.Kind() == reflect.Pointer
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return value, false
+		}
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Slice {
+		return value, value.Len() > 0
+	}
+	return value, isPointer || !value.IsZero()
+}
+
+// parseSort parses "created_at,-id" into SortColumns, "-" means descending
+func parseSort(s string) (columns []SortColumn) {
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		if strings.HasPrefix(term, "-") {
+			columns = append(columns, SortDesc(term[1:]))
+		} else {
+			columns = append(columns, SortAsc(strings.TrimPrefix(term, "+")))
+		}
+	}
+	return columns
+}
+
+// validateFilter checks the sort whitelist and operator arity of a query struct
+func validateFilter(query any) (errorDetail ErrorDetail) {
+	value, ok := structValue(query)
+	if !ok {
+		return nil
+	}
+	spec := getFilterSpec(value.Type())
+
+	for _, field := range spec.sorts {
+		for _, column := range parseSort(value.FieldByIndex(field.index).String()) {
+			if !field.allowed[column.Column] {
+				errorDetail = append(errorDetail, &ErrorDetailElement{
+					Tag:         "sort",
+					Field:       field.name,
+					Kind:        reflect.String,
+					Value:       column.Column,
+					Param:       field.param,
+					StructField: value.Type().FieldByIndex(field.index).Name,
+					Label:       fieldLabel(value.Type().FieldByIndex(field.index)),
+				})
+			}
+		}
+	}
+
+	for _, field := range spec.filters {
+		fieldValue, ok := filterValue(value.FieldByIndex(field.index))
+		if !ok {
+			continue
+		}
+		var valid bool
+		switch field.op {
+		case FilterIn:
+			valid = fieldValue.Kind() == reflect.Slice
+		case FilterBetween:
+			valid = fieldValue.Kind() == reflect.Slice && fieldValue.Len() == 2
+		case FilterLike:
+			valid = fieldValue.Kind() == reflect.String
+		default:
+			valid = fieldValue.Kind() != reflect.Slice
+		}
+		if !valid {
+			errorDetail = append(errorDetail, &ErrorDetailElement{
+				Tag:         "filter_" + field.op,
+				Field:       field.name,
+				Kind:        fieldValue.Kind(),
+				Value:       fieldValue.Interface(),
+				StructField: value.Type().FieldByIndex(field.index).Name,
+				Label:       fieldLabel(value.Type().FieldByIndex(field.index)),
+			})
+		}
+	}
+
+	return errorDetail
+}
+
+// SortColumns returns the columns in sortable fields of a query struct, in order of fields
+func SortColumns(query any) (columns []SortColumn) {
+	value, ok := structValue(query)
+	if !ok {
+		return nil
+	}
+	for _, field := range getFilterSpec(value.Type()).sorts {
+		columns = append(columns, parseSort(value.FieldByIndex(field.index).String())...)
+	}
+	return columns
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Filter is a gorm scope applying filterable and sortable fields of a query struct.
+// Fields not provided, i.e. nil, zero, empty slice, absent or null Optional, are skipped.
+// The query should be validated by ValidateQuery first.
+//
+//	type ListHolesQuery struct {
+//		DivisionID   []int      `query:"division_id" filter:"in"`
+//		CreatedAfter *time.Time `query:"created_after" filter:"created_at,gt"`
+//		Content      string     `query:"content" filter:"like"`
+//		OrderBy      string     `query:"order_by" sort:"id,created_at" default:"-id"`
+//	}
+//
+//	db.Scopes(Filter(&query)).Find(&holes)
+func Filter(query any) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		value, ok := structValue(query)
+		if !ok {
+			return db
+		}
+
+		if errorDetail := validateFilter(query); len(errorDetail) > 0 {
+			_ = db.AddError(&errorDetail)
+			return db
+		}
+
+		spec := getFilterSpec(value.Type())
+		var expressions []clause.Expression
+		for _, field := range spec.filters {
+			fieldValue, ok := filterValue(value.FieldByIndex(field.index))
+			if !ok {
+				continue
+			}
+			expressions = append(expressions, filterExpression(field, fieldValue))
+		}
+		if len(expressions) > 0 {
+			db = db.Where(clause.And(expressions...))
+		}
+
+		for _, field := range spec.sorts {
+			for _, column := range parseSort(value.FieldByIndex(field.index).String()) {
+				db = db.Order(clause.OrderByColumn{Column: filterColumn(column.Column), Desc: column.Desc})
+			}
+		}
+		return db
+	}
+}
+
+func filterExpression(field filterField, value reflect.Value) clause.Expression {
+	switch field.op {
+	case FilterNe:
+		return clause.Neq{Column: field.column, Value: value.Interface()}
+	case FilterIn:
+		values := make([]any, value.Len())
+		for i := range values {
+			values[i] = value.Index(i).Interface()
+		}
+		return clause.IN{Column: field.column, Values: values}
+	case FilterGt:
+		return clause.Gt{Column: field.column, Value: value.Interface()}
+	case FilterGte:
+		return clause.Gte{Column: field.column, Value: value.Interface()}
+	case FilterLt:
+		return clause.Lt{Column: field.column, Value: value.Interface()}
+	case FilterLte:
+		return clause.Lte{Column: field.column, Value: value.Interface()}
+	case FilterLike:
+		return clause.Like{Column: field.column, Value: "%" + likeEscaper.Replace(value.String()) + "%"}
+	case FilterBetween:
+		return clause.Expr{
+			SQL:  "? BETWEEN ? AND ?",
+			Vars: []any{field.column, value.Index(0).Interface(), value.Index(1).Interface()},
+		}
+	default:
+		return clause.Eq{Column: field.column, Value: value.Interface()}
+	}
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testHoleQuery struct {
+	DivisionID []int  `json:"division_id" query:"division_id" filter:"in"`
+	MinLikes   *int   `json:"min_likes" query:"min_likes" filter:"likes,gte"`
+	LikesRange []int  `json:"likes_range" query:"likes_range" filter:"likes,between"`
+	Content    string `json:"content" query:"content" filter:"like"`
+	OrderBy    string `json:"order_by" query:"order_by" sort:"id,likes" default:"-id"`
+}
+
+func TestFilter(t *testing.T) {
+	db := newDryRunDB(t)
+
+	minLikes := 0
+	query := testHoleQuery{
+		DivisionID: []int{1, 2},
+		MinLikes:   &minLikes,
+		LikesRange: []int{1, 10},
+		Content:    "100%",
+		OrderBy:    "likes,-id",
+	}
+
+	var holes []testHole
+	statement := db.Scopes(Filter(&query)).Find(&holes).Statement
+	assert.EqualValues(t,
+		"SELECT * FROM `test_holes` WHERE (`test_holes`.`division_id` IN (?,?) AND `test_holes`.`likes` >= ? AND (`test_holes`.`likes` BETWEEN ? AND ?) AND `test_holes`.`content` LIKE ?) ORDER BY `test_holes`.`likes`,`test_holes`.`id` DESC",
+		statement.SQL.String(),
+	)
+	assert.EqualValues(t, []any{1, 2, 0, 1, 10, `%100\%%`}, statement.Vars)
+
+	// zero values are skipped
+	statement = db.Scopes(Filter(&testHoleQuery{})).Find(&holes).Statement
+	assert.EqualValues(t, "SELECT * FROM `test_holes`", statement.SQL.String())
+
+	// invalid sort column
+	err := db.Scopes(Filter(&testHoleQuery{OrderBy: "content"})).Find(&holes).Error
+	assert.IsType(t, &ErrorDetail{}, err)
+
+	assert.EqualValues(t, []SortColumn{SortAsc("likes"), SortDesc("id")}, SortColumns(&query))
+
+	// present Optional is provided even if zero, absent and null ones are skipped
+	var optionalQuery struct {
+		Hidden  Optional[bool]   `json:"hidden" filter:"eq"`
+		Content Optional[string] `json:"content" filter:"like"`
+		Likes   Optional[int]    `json:"likes" filter:"gt"`
+	}
+	optionalQuery.Hidden = Some(false)
+	optionalQuery.Content = Some("a")
+	optionalQuery.Likes = Optional[int]{Present: true, Null: true}
+	statement = db.Scopes(Filter(&optionalQuery)).Find(&holes).Statement
+	assert.EqualValues(t,
+		"SELECT * FROM `test_holes` WHERE (`test_holes`.`hidden` = ? AND `test_holes`.`content` LIKE ?)",
+		statement.SQL.String(),
+	)
+	assert.EqualValues(t, []any{false, "%a%"}, statement.Vars)
+}
+
+func TestFilterQuery(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/holes", func(c *fiber.Ctx) error {
+		var query testHoleQuery
+		if err := ValidateQuery(c, &query); err != nil {
+			return err
+		}
+		return c.JSON(query)
+	})
+	RegisterApp(app)
+
+	var query testHoleQuery
+	DefaultTester.Get(t, RequestConfig{Route: "/holes?division_id=1&division_id=2", ResponseModel: &query})
+	assert.EqualValues(t, []int{1, 2}, query.DivisionID)
+	assert.EqualValues(t, "-id", query.OrderBy)
+
+	var httpError HttpError
+	DefaultTester.Get(t, RequestConfig{
+		Route:          "/holes?order_by=content&likes_range=1",
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.Len(t, *httpError.Detail, 2)
+	assert.EqualValues(t, "order_by不支持按content排序, likes_range必须为两个值", httpError.Message)
+}
@@ -0,0 +1,37 @@
+package common
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// HandlerFunc is a typed handler, it can be unit tested by calling it with a request directly
+type HandlerFunc[Req, Resp any] func(c *fiber.Ctx, req *Req) (*Resp, error)
+
+// Handle adapts a typed handler into fiber.Handler.
+//
+// The request is bound and validated by Bind, i.e. query as ValidateQuery does and body as ValidateBody does,
+// plus path params, headers and cookies. The response is rendered by the response helpers
+// with status 200, or the status set by fn; a nil response is sent as 204 No Content.
+// Errors are returned to ErrorHandler.
+//
+//	app.Get("/holes/:id", Handle(GetHole))
+//
+//	func GetHole(c *fiber.Ctx, req *GetHoleRequest) (*Hole, error)
+func Handle[Req, Resp any](fn HandlerFunc[Req, Resp]) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req Req
+		if err := Bind(c, &req); err != nil {
+			return err
+		}
+
+		resp, err := fn(c, &req)
+		if err != nil {
+			return err
+		}
+
+		if resp == nil {
+			return NoContent(c)
+		}
+		return sendData(c, c.Response().StatusCode(), resp)
+	}
+}
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testGetFloorRequest struct {
+	ID int `param:"id" validate:"min=1"`
+}
+
+func testGetFloor(_ *fiber.Ctx, req *testGetFloorRequest) (*testFloor, error) {
+	if req.ID > 10 {
+		return nil, NotFound("Floor Not Found")
+	}
+	return &testFloor{ID: req.ID}, nil
+}
+
+type testCreateFloorRequest struct {
+	Content string `json:"content" validate:"required"`
+}
+
+func testCreateFloor(c *fiber.Ctx, req *testCreateFloorRequest) (*testFloor, error) {
+	c.Status(fiber.StatusCreated)
+	return &testFloor{ID: 11, Content: req.Content}, nil
+}
+
+func TestHandle(t *testing.T) {
+	// typed handlers can be tested without fiber
+	floor, err := testGetFloor(nil, &testGetFloorRequest{ID: 1})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, floor.ID)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/floors/:id", Handle(testGetFloor))
+	app.Post("/floors", Handle(testCreateFloor))
+	app.Delete("/floors/:id", Handle(func(_ *fiber.Ctx, _ *testGetFloorRequest) (*struct{}, error) {
+		return nil, nil
+	}))
+	RegisterApp(app)
+
+	DefaultTester.Get(t, RequestConfig{Route: "/floors/1", ExpectedBody: `{"id":1,"content":""}`})
+	DefaultTester.Get(t, RequestConfig{Route: "/floors/11", ExpectedStatus: 404})
+	DefaultTester.Get(t, RequestConfig{Route: "/floors/0", ExpectedStatus: 400})
+	DefaultTester.Post(t, RequestConfig{Route: "/floors", RequestBody: Map{"content": "a"}, ExpectedBody: `{"id":11,"content":"a"}`})
+	DefaultTester.Post(t, RequestConfig{Route: "/floors", RequestBody: Map{}, ExpectedStatus: 400})
+	DefaultTester.Delete(t, RequestConfig{Route: "/floors/1", ExpectedStatus: 204})
+}
@@ -0,0 +1,609 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	LanguageZH = "zh-CN"
+	LanguageEN = "en"
+)
+
+// DefaultLanguage is used when Accept-Language matches no catalog, and by ErrorDetailElement.Error
+var DefaultLanguage = LanguageZH
+
+// messages are templates keyed by language and tag. Placeholders {field}, {param}, {value}
+// and {struct_field} are replaced by the ErrorDetailElement, with Label instead of field names if set.
+// Tags with .string or .items suffix are used for strings, or slices and maps respectively, e.g. min.string.
+// Tag "default" is used for unknown tags.
+var messages = map[string]map[string]string{
+	LanguageZH: {
+		"default":                       "{struct_field}格式不正确",
+		"required":                      "{field}不能为空",
+		"required_if":                   "{field}不能为空",
+		"required_unless":               "{field}不能为空",
+		"required_with":                 "{field}不能为空",
+		"required_without":              "{field}不能为空",
+		"excluded_if":                   "{field}必须为空",
+		"excluded_unless":               "{field}必须为空",
+		"excluded_with":                 "{field}必须为空",
+		"excluded_without":              "{field}必须为空",
+		"isdefault":                     "{field}必须为空",
+		"len":                           "{field}必须为{param}",
+		"len.string":                    "{field}长度必须为{param}字符",
+		"len.items":                     "{field}必须为{param}项",
+		"min":                           "{field}至少为{param}",
+		"min.string":                    "{field}至少{param}字符",
+		"min.items":                     "{field}至少{param}项",
+		"max":                           "{field}至多为{param}",
+		"max.string":                    "{field}限长{param}字符",
+		"max.items":                     "{field}至多{param}项",
+		"eq":                            "{field}必须等于{param}",
+		"ne":                            "{field}不能等于{param}",
+		"gt":                            "{field}必须大于{param}",
+		"gt.string":                     "{field}必须多于{param}字符",
+		"gt.items":                      "{field}必须多于{param}项",
+		"gte":                           "{field}必须大于或等于{param}",
+		"gte.string":                    "{field}至少{param}字符",
+		"gte.items":                     "{field}至少{param}项",
+		"lt":                            "{field}必须小于{param}",
+		"lt.string":                     "{field}必须少于{param}字符",
+		"lt.items":                      "{field}必须少于{param}项",
+		"lte":                           "{field}必须小于或等于{param}",
+		"lte.string":                    "{field}限长{param}字符",
+		"lte.items":                     "{field}至多{param}项",
+		"eqfield":                       "{field}必须等于{param}",
+		"nefield":                       "{field}不能等于{param}",
+		"gtfield":                       "{field}必须大于{param}",
+		"gtefield":                      "{field}必须大于或等于{param}",
+		"ltfield":                       "{field}必须小于{param}",
+		"ltefield":                      "{field}必须小于或等于{param}",
+		"eqcsfield":                     "{field}必须等于{param}",
+		"necsfield":                     "{field}不能等于{param}",
+		"gtcsfield":                     "{field}必须大于{param}",
+		"gtecsfield":                    "{field}必须大于或等于{param}",
+		"ltcsfield":                     "{field}必须小于{param}",
+		"ltecsfield":                    "{field}必须小于或等于{param}",
+		"fieldcontains":                 "{field}必须包含{param}",
+		"fieldexcludes":                 "{field}不能包含{param}",
+		"oneof":                         "{field}必须是[{param}]中的一个",
+		"unique":                        "{field}不能包含重复项",
+		"contains":                      "{field}必须包含{param}",
+		"excludes":                      "{field}不能包含{param}",
+		"startswith":                    "{field}必须以{param}开头",
+		"endswith":                      "{field}必须以{param}结尾",
+		"lowercase":                     "{field}必须是小写",
+		"uppercase":                     "{field}必须是大写",
+		"alpha":                         "{field}只能包含字母",
+		"alphanum":                      "{field}只能包含字母和数字",
+		"ascii":                         "{field}只能包含ASCII字符",
+		"numeric":                       "{field}必须是有效的数值",
+		"number":                        "{field}必须是有效的数字",
+		"boolean":                       "{field}必须是布尔值",
+		"email":                         "邮箱格式不正确",
+		"url":                           "{field}必须是有效的URL",
+		"uri":                           "{field}必须是有效的URI",
+		"uuid":                          "{field}必须是有效的UUID",
+		"uuid4":                         "{field}必须是有效的UUID",
+		"datetime":                      "{field}的格式必须是{param}",
+		"ip":                            "{field}必须是有效的IP地址",
+		"ipv4":                          "{field}必须是有效的IPv4地址",
+		"ipv6":                          "{field}必须是有效的IPv6地址",
+		"hostname":                      "{field}必须是有效的主机名",
+		"json":                          "{field}必须是有效的JSON",
+		"base64":                        "{field}必须是有效的Base64字符串",
+		"hexcolor":                      "{field}必须是有效的十六进制颜色",
+		"e164":                          "{field}必须是有效的E.164手机号",
+		"required_with_all":             "{field}不能为空",
+		"required_without_all":          "{field}不能为空",
+		"skip_unless":                   "{field}不能为空",
+		"excluded_with_all":             "{field}必须为空",
+		"excluded_without_all":          "{field}必须为空",
+		"eq_ignore_case":                "{field}必须等于{param}",
+		"ne_ignore_case":                "{field}不能等于{param}",
+		"alphaunicode":                  "{field}只能包含字母",
+		"alphanumunicode":               "{field}只能包含字母和数字",
+		"hexadecimal":                   "{field}必须是有效的十六进制数",
+		"rgb":                           "{field}必须是有效的RGB颜色",
+		"rgba":                          "{field}必须是有效的RGBA颜色",
+		"hsl":                           "{field}必须是有效的HSL颜色",
+		"hsla":                          "{field}必须是有效的HSLA颜色",
+		"iscolor":                       "{field}必须是有效的颜色",
+		"http_url":                      "{field}必须是有效的HTTP URL",
+		"urn_rfc2141":                   "{field}必须是有效的URN",
+		"file":                          "{field}必须是存在的文件",
+		"filepath":                      "{field}必须是有效的文件路径",
+		"base64url":                     "{field}必须是有效的Base64 URL字符串",
+		"base64rawurl":                  "{field}必须是有效的Base64 URL字符串",
+		"containsany":                   "{field}必须包含[{param}]中的至少一个字符",
+		"containsrune":                  "{field}必须包含{param}",
+		"excludesall":                   "{field}不能包含[{param}]中的字符",
+		"excludesrune":                  "{field}不能包含{param}",
+		"startsnotwith":                 "{field}不能以{param}开头",
+		"endsnotwith":                   "{field}不能以{param}结尾",
+		"image":                         "{field}必须是有效的图片",
+		"isbn":                          "{field}必须是有效的ISBN",
+		"isbn10":                        "{field}必须是有效的ISBN-10",
+		"isbn13":                        "{field}必须是有效的ISBN-13",
+		"issn":                          "{field}必须是有效的ISSN",
+		"eth_addr":                      "{field}必须是有效的以太坊地址",
+		"eth_addr_checksum":             "{field}必须是有效的以太坊地址",
+		"btc_addr":                      "{field}必须是有效的比特币地址",
+		"btc_addr_bech32":               "{field}必须是有效的比特币Bech32地址",
+		"uuid3":                         "{field}必须是有效的UUID",
+		"uuid5":                         "{field}必须是有效的UUID",
+		"uuid_rfc4122":                  "{field}必须是有效的UUID",
+		"uuid3_rfc4122":                 "{field}必须是有效的UUID",
+		"uuid4_rfc4122":                 "{field}必须是有效的UUID",
+		"uuid5_rfc4122":                 "{field}必须是有效的UUID",
+		"ulid":                          "{field}必须是有效的ULID",
+		"md4":                           "{field}必须是有效的MD4哈希",
+		"md5":                           "{field}必须是有效的MD5哈希",
+		"sha256":                        "{field}必须是有效的SHA256哈希",
+		"sha384":                        "{field}必须是有效的SHA384哈希",
+		"sha512":                        "{field}必须是有效的SHA512哈希",
+		"ripemd128":                     "{field}必须是有效的RIPEMD-128哈希",
+		"ripemd160":                     "{field}必须是有效的RIPEMD-160哈希",
+		"tiger128":                      "{field}必须是有效的TIGER128哈希",
+		"tiger160":                      "{field}必须是有效的TIGER160哈希",
+		"tiger192":                      "{field}必须是有效的TIGER192哈希",
+		"printascii":                    "{field}只能包含可打印的ASCII字符",
+		"multibyte":                     "{field}必须包含多字节字符",
+		"datauri":                       "{field}必须是有效的Data URI",
+		"latitude":                      "{field}必须是有效的纬度",
+		"longitude":                     "{field}必须是有效的经度",
+		"ssn":                           "{field}必须是有效的SSN",
+		"cidr":                          "{field}必须是有效的CIDR",
+		"cidrv4":                        "{field}必须是有效的IPv4 CIDR",
+		"cidrv6":                        "{field}必须是有效的IPv6 CIDR",
+		"tcp_addr":                      "{field}必须是有效的TCP地址",
+		"tcp4_addr":                     "{field}必须是有效的IPv4 TCP地址",
+		"tcp6_addr":                     "{field}必须是有效的IPv6 TCP地址",
+		"udp_addr":                      "{field}必须是有效的UDP地址",
+		"udp4_addr":                     "{field}必须是有效的IPv4 UDP地址",
+		"udp6_addr":                     "{field}必须是有效的IPv6 UDP地址",
+		"ip_addr":                       "{field}必须是可解析的IP地址",
+		"ip4_addr":                      "{field}必须是可解析的IPv4地址",
+		"ip6_addr":                      "{field}必须是可解析的IPv6地址",
+		"unix_addr":                     "{field}必须是有效的Unix地址",
+		"mac":                           "{field}必须是有效的MAC地址",
+		"hostname_rfc1123":              "{field}必须是有效的主机名",
+		"fqdn":                          "{field}必须是有效的完整域名",
+		"hostname_port":                 "{field}必须是有效的主机名和端口",
+		"dns_rfc1035_label":             "{field}必须是有效的DNS标签",
+		"html":                          "{field}必须是有效的HTML",
+		"html_encoded":                  "{field}必须是HTML编码的",
+		"url_encoded":                   "{field}必须是URL编码的",
+		"dir":                           "{field}必须是存在的目录",
+		"dirpath":                       "{field}必须是有效的目录路径",
+		"jwt":                           "{field}必须是有效的JWT",
+		"timezone":                      "{field}必须是有效的时区",
+		"country_code":                  "{field}必须是有效的国家代码",
+		"iso3166_1_alpha2":              "{field}必须是有效的国家代码",
+		"iso3166_1_alpha3":              "{field}必须是有效的国家代码",
+		"iso3166_1_alpha_numeric":       "{field}必须是有效的国家代码",
+		"iso3166_2":                     "{field}必须是有效的地区代码",
+		"iso4217":                       "{field}必须是有效的货币代码",
+		"iso4217_numeric":               "{field}必须是有效的货币代码",
+		"bcp47_language_tag":            "{field}必须是有效的语言标签",
+		"postcode_iso3166_alpha2":       "{field}必须是有效的邮政编码",
+		"postcode_iso3166_alpha2_field": "{field}必须是有效的邮政编码",
+		"bic":                           "{field}必须是有效的BIC",
+		"semver":                        "{field}必须是有效的语义化版本",
+		"credit_card":                   "{field}必须是有效的信用卡号",
+		"cve":                           "{field}必须是有效的CVE编号",
+		"luhn_checksum":                 "{field}的校验位不正确",
+		"mongodb":                       "{field}必须是有效的MongoDB ObjectID",
+		"cron":                          "{field}必须是有效的cron表达式",
+		"spicedb":                       "{field}必须是有效的SpiceDB标识",
+		"sort":                          "{field}不支持按{value}排序",
+		"filter_eq":                     "{field}不能为多个值",
+		"filter_ne":                     "{field}不能为多个值",
+		"filter_gt":                     "{field}不能为多个值",
+		"filter_gte":                    "{field}不能为多个值",
+		"filter_lt":                     "{field}不能为多个值",
+		"filter_lte":                    "{field}不能为多个值",
+		"filter_in":                     "{field}必须为列表",
+		"filter_like":                   "{field}必须为字符串",
+		"filter_between":                "{field}必须为两个值",
+		"fields":                        "字段{field}不存在",
+		"type":                          "{field}类型不正确",
+		"unknown_field":                 "未知字段{field}",
+		"duplicate_key":                 "重复字段{field}",
+		"trailing_data":                 "请求体末尾有多余数据",
+		"patch":                         "{field}不允许修改",
+		"config":                        "{field}的值{value}无效，来自{param}",
+		"max_size":                      "{field}大小不能超过{param}",
+		"mime":                          "{field}文件类型不支持",
+		"min_runes":                     "{field}至少{param}字符",
+		"max_runes":                     "{field}限长{param}字符",
+		"min_graphemes":                 "{field}至少{param}字符",
+		"max_graphemes":                 "{field}限长{param}字符",
+		"sensitive":                     "{field}包含敏感词",
+		"cn_mobile":                     "手机号格式不正确",
+		"campus_email":                  "请使用校园邮箱",
+		"safe_markdown":                 "{field}包含不安全的内容",
+		"hex_color":                     "{field}必须是有效的十六进制颜色",
+		"no_emoji":                      "{field}不能包含表情符号",
+		"no_control":                    "{field}不能包含控制字符",
+	},
+	LanguageEN: {
+		"default":                       "{struct_field} is invalid",
+		"required":                      "{field} is required",
+		"required_if":                   "{field} is required",
+		"required_unless":               "{field} is required",
+		"required_with":                 "{field} is required",
+		"required_without":              "{field} is required",
+		"excluded_if":                   "{field} must be empty",
+		"excluded_unless":               "{field} must be empty",
+		"excluded_with":                 "{field} must be empty",
+		"excluded_without":              "{field} must be empty",
+		"isdefault":                     "{field} must be empty",
+		"len":                           "{field} must be {param}",
+		"len.string":                    "{field} must be {param} characters long",
+		"len.items":                     "{field} must contain {param} items",
+		"min":                           "{field} must be {param} or greater",
+		"min.string":                    "{field} must be at least {param} characters long",
+		"min.items":                     "{field} must contain at least {param} items",
+		"max":                           "{field} must be {param} or less",
+		"max.string":                    "{field} must be at most {param} characters long",
+		"max.items":                     "{field} must contain at most {param} items",
+		"eq":                            "{field} must be equal to {param}",
+		"ne":                            "{field} must not be equal to {param}",
+		"gt":                            "{field} must be greater than {param}",
+		"gt.string":                     "{field} must be longer than {param} characters",
+		"gt.items":                      "{field} must contain more than {param} items",
+		"gte":                           "{field} must be {param} or greater",
+		"gte.string":                    "{field} must be at least {param} characters long",
+		"gte.items":                     "{field} must contain at least {param} items",
+		"lt":                            "{field} must be less than {param}",
+		"lt.string":                     "{field} must be shorter than {param} characters",
+		"lt.items":                      "{field} must contain less than {param} items",
+		"lte":                           "{field} must be {param} or less",
+		"lte.string":                    "{field} must be at most {param} characters long",
+		"lte.items":                     "{field} must contain at most {param} items",
+		"eqfield":                       "{field} must be equal to {param}",
+		"nefield":                       "{field} must not be equal to {param}",
+		"gtfield":                       "{field} must be greater than {param}",
+		"gtefield":                      "{field} must be greater than or equal to {param}",
+		"ltfield":                       "{field} must be less than {param}",
+		"ltefield":                      "{field} must be less than or equal to {param}",
+		"eqcsfield":                     "{field} must be equal to {param}",
+		"necsfield":                     "{field} must not be equal to {param}",
+		"gtcsfield":                     "{field} must be greater than {param}",
+		"gtecsfield":                    "{field} must be greater than or equal to {param}",
+		"ltcsfield":                     "{field} must be less than {param}",
+		"ltecsfield":                    "{field} must be less than or equal to {param}",
+		"fieldcontains":                 "{field} must contain {param}",
+		"fieldexcludes":                 "{field} must not contain {param}",
+		"oneof":                         "{field} must be one of [{param}]",
+		"unique":                        "{field} must not contain duplicate items",
+		"contains":                      "{field} must contain {param}",
+		"excludes":                      "{field} must not contain {param}",
+		"startswith":                    "{field} must start with {param}",
+		"endswith":                      "{field} must end with {param}",
+		"lowercase":                     "{field} must be lowercase",
+		"uppercase":                     "{field} must be uppercase",
+		"alpha":                         "{field} can only contain letters",
+		"alphanum":                      "{field} can only contain letters and numbers",
+		"ascii":                         "{field} can only contain ascii characters",
+		"numeric":                       "{field} must be a valid numeric value",
+		"number":                        "{field} must be a valid number",
+		"boolean":                       "{field} must be a boolean",
+		"email":                         "{field} must be a valid email address",
+		"url":                           "{field} must be a valid URL",
+		"uri":                           "{field} must be a valid URI",
+		"uuid":                          "{field} must be a valid UUID",
+		"uuid4":                         "{field} must be a valid UUID",
+		"datetime":                      "{field} must be in the format {param}",
+		"ip":                            "{field} must be a valid IP address",
+		"ipv4":                          "{field} must be a valid IPv4 address",
+		"ipv6":                          "{field} must be a valid IPv6 address",
+		"hostname":                      "{field} must be a valid hostname",
+		"json":                          "{field} must be valid JSON",
+		"base64":                        "{field} must be a valid Base64 string",
+		"hexcolor":                      "{field} must be a valid hex color",
+		"e164":                          "{field} must be a valid E.164 phone number",
+		"required_with_all":             "{field} is required",
+		"required_without_all":          "{field} is required",
+		"skip_unless":                   "{field} is required",
+		"excluded_with_all":             "{field} must be empty",
+		"excluded_without_all":          "{field} must be empty",
+		"eq_ignore_case":                "{field} must be equal to {param}",
+		"ne_ignore_case":                "{field} must not be equal to {param}",
+		"alphaunicode":                  "{field} can only contain letters",
+		"alphanumunicode":               "{field} can only contain letters and numbers",
+		"hexadecimal":                   "{field} must be a valid hexadecimal",
+		"rgb":                           "{field} must be a valid RGB color",
+		"rgba":                          "{field} must be a valid RGBA color",
+		"hsl":                           "{field} must be a valid HSL color",
+		"hsla":                          "{field} must be a valid HSLA color",
+		"iscolor":                       "{field} must be a valid color",
+		"http_url":                      "{field} must be a valid HTTP URL",
+		"urn_rfc2141":                   "{field} must be a valid URN",
+		"file":                          "{field} must be an existing file",
+		"filepath":                      "{field} must be a valid file path",
+		"base64url":                     "{field} must be a valid Base64 URL string",
+		"base64rawurl":                  "{field} must be a valid Base64 URL string",
+		"containsany":                   "{field} must contain at least one of the characters {param}",
+		"containsrune":                  "{field} must contain {param}",
+		"excludesall":                   "{field} must not contain any of the characters {param}",
+		"excludesrune":                  "{field} must not contain {param}",
+		"startsnotwith":                 "{field} must not start with {param}",
+		"endsnotwith":                   "{field} must not end with {param}",
+		"image":                         "{field} must be a valid image",
+		"isbn":                          "{field} must be a valid ISBN",
+		"isbn10":                        "{field} must be a valid ISBN-10",
+		"isbn13":                        "{field} must be a valid ISBN-13",
+		"issn":                          "{field} must be a valid ISSN",
+		"eth_addr":                      "{field} must be a valid Ethereum address",
+		"eth_addr_checksum":             "{field} must be a valid Ethereum address",
+		"btc_addr":                      "{field} must be a valid Bitcoin address",
+		"btc_addr_bech32":               "{field} must be a valid Bech32 Bitcoin address",
+		"uuid3":                         "{field} must be a valid UUID",
+		"uuid5":                         "{field} must be a valid UUID",
+		"uuid_rfc4122":                  "{field} must be a valid UUID",
+		"uuid3_rfc4122":                 "{field} must be a valid UUID",
+		"uuid4_rfc4122":                 "{field} must be a valid UUID",
+		"uuid5_rfc4122":                 "{field} must be a valid UUID",
+		"ulid":                          "{field} must be a valid ULID",
+		"md4":                           "{field} must be a valid MD4 hash",
+		"md5":                           "{field} must be a valid MD5 hash",
+		"sha256":                        "{field} must be a valid SHA256 hash",
+		"sha384":                        "{field} must be a valid SHA384 hash",
+		"sha512":                        "{field} must be a valid SHA512 hash",
+		"ripemd128":                     "{field} must be a valid RIPEMD-128 hash",
+		"ripemd160":                     "{field} must be a valid RIPEMD-160 hash",
+		"tiger128":                      "{field} must be a valid TIGER128 hash",
+		"tiger160":                      "{field} must be a valid TIGER160 hash",
+		"tiger192":                      "{field} must be a valid TIGER192 hash",
+		"printascii":                    "{field} can only contain printable ascii characters",
+		"multibyte":                     "{field} must contain multibyte characters",
+		"datauri":                       "{field} must be a valid data URI",
+		"latitude":                      "{field} must be a valid latitude",
+		"longitude":                     "{field} must be a valid longitude",
+		"ssn":                           "{field} must be a valid SSN",
+		"cidr":                          "{field} must be a valid CIDR notation",
+		"cidrv4":                        "{field} must be a valid IPv4 CIDR notation",
+		"cidrv6":                        "{field} must be a valid IPv6 CIDR notation",
+		"tcp_addr":                      "{field} must be a valid TCP address",
+		"tcp4_addr":                     "{field} must be a valid IPv4 TCP address",
+		"tcp6_addr":                     "{field} must be a valid IPv6 TCP address",
+		"udp_addr":                      "{field} must be a valid UDP address",
+		"udp4_addr":                     "{field} must be a valid IPv4 UDP address",
+		"udp6_addr":                     "{field} must be a valid IPv6 UDP address",
+		"ip_addr":                       "{field} must be a resolvable IP address",
+		"ip4_addr":                      "{field} must be a resolvable IPv4 address",
+		"ip6_addr":                      "{field} must be a resolvable IPv6 address",
+		"unix_addr":                     "{field} must be a valid unix address",
+		"mac":                           "{field} must be a valid MAC address",
+		"hostname_rfc1123":              "{field} must be a valid hostname",
+		"fqdn":                          "{field} must be a valid FQDN",
+		"hostname_port":                 "{field} must be a valid host and port",
+		"dns_rfc1035_label":             "{field} must be a valid DNS label",
+		"html":                          "{field} must be valid HTML",
+		"html_encoded":                  "{field} must be HTML-encoded",
+		"url_encoded":                   "{field} must be URL-encoded",
+		"dir":                           "{field} must be an existing directory",
+		"dirpath":                       "{field} must be a valid directory path",
+		"jwt":                           "{field} must be a valid JWT",
+		"timezone":                      "{field} must be a valid time zone",
+		"country_code":                  "{field} must be a valid country code",
+		"iso3166_1_alpha2":              "{field} must be a valid country code",
+		"iso3166_1_alpha3":              "{field} must be a valid country code",
+		"iso3166_1_alpha_numeric":       "{field} must be a valid country code",
+		"iso3166_2":                     "{field} must be a valid subdivision code",
+		"iso4217":                       "{field} must be a valid currency code",
+		"iso4217_numeric":               "{field} must be a valid currency code",
+		"bcp47_language_tag":            "{field} must be a valid language tag",
+		"postcode_iso3166_alpha2":       "{field} must be a valid postcode",
+		"postcode_iso3166_alpha2_field": "{field} must be a valid postcode",
+		"bic":                           "{field} must be a valid BIC",
+		"semver":                        "{field} must be a valid semantic version",
+		"credit_card":                   "{field} must be a valid credit card number",
+		"cve":                           "{field} must be a valid CVE identifier",
+		"luhn_checksum":                 "{field} must have a valid Luhn checksum",
+		"mongodb":                       "{field} must be a valid MongoDB ObjectID",
+		"cron":                          "{field} must be a valid cron expression",
+		"spicedb":                       "{field} must be a valid SpiceDB identifier",
+		"sort":                          "{field} can not be sorted by {value}",
+		"filter_eq":                     "{field} must be a single value",
+		"filter_ne":                     "{field} must be a single value",
+		"filter_gt":                     "{field} must be a single value",
+		"filter_gte":                    "{field} must be a single value",
+		"filter_lt":                     "{field} must be a single value",
+		"filter_lte":                    "{field} must be a single value",
+		"filter_in":                     "{field} must be a list",
+		"filter_like":                   "{field} must be a string",
+		"filter_between":                "{field} must have two values",
+		"fields":                        "field {field} does not exist",
+		"type":                          "{field} has an invalid type",
+		"unknown_field":                 "unknown field {field}",
+		"duplicate_key":                 "duplicate field {field}",
+		"trailing_data":                 "unexpected data after request body",
+		"patch":                         "{field} can not be patched",
+		"config":                        "{field} has an invalid value {value} from {param}",
+		"max_size":                      "{field} must not be larger than {param}",
+		"mime":                          "{field} has an unsupported file type",
+		"min_runes":                     "{field} must be at least {param} characters long",
+		"max_runes":                     "{field} must be at most {param} characters long",
+		"min_graphemes":                 "{field} must be at least {param} characters long",
+		"max_graphemes":                 "{field} must be at most {param} characters long",
+		"sensitive":                     "{field} contains sensitive words",
+		"cn_mobile":                     "{field} must be a valid mobile number",
+		"campus_email":                  "{field} must be a campus email address",
+		"safe_markdown":                 "{field} contains unsafe content",
+		"hex_color":                     "{field} must be a valid hex color",
+		"no_emoji":                      "{field} must not contain emoji",
+		"no_control":                    "{field} must not contain control characters",
+	},
+}
+
+type fieldMessageKey struct {
+	language, field, tag string
+}
+
+var (
+	messagesMu    sync.RWMutex
+	fieldMessages = make(map[fieldMessageKey]string)
+	// languages with a catalog in order of registration, so that Language matches deterministically
+	languages = []string{LanguageZH, LanguageEN}
+)
+
+// RegisterMessage adds or overrides the message template of tag in language, a new language can be added this way.
+//
+//	RegisterMessage(LanguageEN, "min.string", "{field} is too short")
+func RegisterMessage(language, tag, message string) {
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+	if messages[language] == nil {
+		messages[language] = make(map[string]string)
+		languages = append(languages, language)
+	}
+	messages[language][tag] = message
+}
+
+// RegisterFieldMessage overrides the message of tag for a field, field is matched against Field or StructField
+//
+//	RegisterFieldMessage(LanguageZH, "content", "max", "内容太长了")
+func RegisterFieldMessage(language, field, tag, message string) {
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+	fieldMessages[fieldMessageKey{language, field, tag}] = message
+}
+
+// messageKind returns the suffix of tag for kinds that have different messages
+func messageKind(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return ".string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return ".items"
+	default:
+		return ""
+	}
+}
+
+// Translate returns the message of e in language, the explicit Message is returned as is if set
+func (e *ErrorDetailElement) Translate(language string) string {
+	if e.Message != "" {
+		return e.Message
+	}
+
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+
+	message, ok := "", false
+	for _, lang := range []string{language, DefaultLanguage} {
+		for _, field := range []string{e.Field, e.StructField} {
+			if message, ok = fieldMessages[fieldMessageKey{lang, field, e.Tag}]; ok {
+				break
+			}
+		}
+		if !ok {
+			if suffix := messageKind(e.Kind); suffix != "" {
+				message, ok = messages[lang][e.Tag+suffix]
+			}
+		}
+		if !ok {
+			message, ok = messages[lang][e.Tag]
+		}
+		if ok {
+			break
+		}
+	}
+	if !ok {
+		if message, ok = messages[language]["default"]; !ok {
+			message = messages[DefaultLanguage]["default"]
+		}
+	}
+
+	var value string
+	if e.Value != nil {
+		value = fmt.Sprint(e.Value)
+	}
+	field, structField, param := e.Field, e.StructField, e.Param
+	if e.Label != "" {
+		field, structField = e.Label, e.Label
+	}
+	if e.paramLabel != "" {
+		param = e.paramLabel
+	}
+	return strings.NewReplacer(
+		"{field}", field,
+		"{param}", param,
+		"{value}", value,
+		"{struct_field}", structField,
+	).Replace(message)
+}
+
+// Translate returns a copy of e with messages in language
+func (e ErrorDetail) Translate(language string) ErrorDetail {
+	translated := make(ErrorDetail, len(e))
+	for i, element := range e {
+		element := *element
+		element.Message = element.Translate(language)
+		translated[i] = &element
+	}
+	return translated
+}
+
+// Language returns the language of the request by Accept-Language header that has a message catalog,
+// or DefaultLanguage. A language also matches by its primary tag, e.g. en-US matches en and zh matches zh-CN,
+// the first registered one is used if several languages have the same primary tag.
+func Language(c *fiber.Ctx) string {
+	header := c.Get(fiber.HeaderAcceptLanguage)
+	if header == "" {
+		return DefaultLanguage
+	}
+
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+	var accepted []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if tag != "" && quality > 0 {
+			accepted = append(accepted, weighted{tag, quality})
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+	for _, item := range accepted {
+		if item.tag == "*" {
+			return DefaultLanguage
+		}
+		primary, _, _ := strings.Cut(item.tag, "-")
+		var match string
+		for _, language := range languages {
+			if strings.EqualFold(language, item.tag) {
+				return language
+			}
+			if languagePrimary, _, _ := strings.Cut(language, "-"); match == "" && strings.EqualFold(languagePrimary, primary) {
+				match = language
+			}
+		}
+		if match != "" {
+			return match
+		}
+	}
+	return DefaultLanguage
+}
@@ -0,0 +1,148 @@
+package common
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslate(t *testing.T) {
+	for _, c := range []struct {
+		element  ErrorDetailElement
+		language string
+		expected string
+	}{
+		{ErrorDetailElement{Tag: "min", Field: "content", Param: "1", Kind: reflect.String}, LanguageZH, "content至少1字符"},
+		{ErrorDetailElement{Tag: "min", Field: "size", Param: "1", Kind: reflect.Int}, LanguageZH, "size至少为1"},
+		{ErrorDetailElement{Tag: "min", Field: "tags", Param: "1", Kind: reflect.Slice}, LanguageEN, "tags must contain at least 1 items"},
+		{ErrorDetailElement{Tag: "oneof", Field: "order", Param: "id time"}, LanguageEN, "order must be one of [id time]"},
+		{ErrorDetailElement{Tag: "sort", Field: "order_by", Value: "content"}, LanguageEN, "order_by can not be sorted by content"},
+		{ErrorDetailElement{Tag: "unknown", StructField: "Content"}, LanguageEN, "Content is invalid"},
+		{ErrorDetailElement{Tag: "filter_gt", Field: "likes", Kind: reflect.Slice}, LanguageZH, "likes不能为多个值"},
+		{ErrorDetailElement{Tag: "filter_in", Field: "division_id", Kind: reflect.Int}, LanguageEN, "division_id must be a list"},
+		{ErrorDetailElement{Tag: "config", Field: "database.port", Param: "file", Value: "abc"}, LanguageEN, "database.port has an invalid value abc from file"},
+		{ErrorDetailElement{Tag: "required", Field: "content"}, "fr", "content不能为空"},
+		{ErrorDetailElement{Tag: "required", Message: "custom"}, LanguageEN, "custom"},
+	} {
+		assert.EqualValues(t, c.expected, c.element.Translate(c.language))
+	}
+
+	RegisterMessage(LanguageEN, "required", "{field} is missing")
+	RegisterFieldMessage(LanguageZH, "Title", "max", "标题太长了")
+	defer RegisterMessage(LanguageEN, "required", "{field} is required")
+
+	element := ErrorDetailElement{Tag: "required", Field: "content"}
+	assert.EqualValues(t, "content is missing", element.Translate(LanguageEN))
+	element = ErrorDetailElement{Tag: "max", Field: "title", StructField: "Title", Param: "10", Kind: reflect.String}
+	assert.EqualValues(t, "title must be at most 10 characters long", element.Translate(LanguageEN))
+	assert.EqualValues(t, "标题太长了", element.Error())
+}
+
+func TestLanguage(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/language", func(c *fiber.Ctx) error {
+		return c.SendString(Language(c))
+	})
+	app.Post("/floors", func(c *fiber.Ctx) error {
+		var body struct {
+			Content string `json:"content" validate:"required,max=5"`
+		}
+		return ValidateBody(c, &body)
+	})
+	app.Get("/http-error", func(c *fiber.Ctx) error {
+		detail := ErrorDetail{{Tag: "required", Field: "content"}}
+		return &HttpError{Code: 400, Message: detail.Error(), Detail: &detail}
+	})
+	RegisterApp(app)
+
+	for header, expected := range map[string]string{
+		"":                          LanguageZH,
+		"en-US,en;q=0.9":            LanguageEN,
+		"fr;q=0.9, en;q=0.8, zh":    LanguageZH,
+		"fr, en-GB;q=0.5":           LanguageEN,
+		"zh-TW":                     LanguageZH,
+		"de":                        LanguageZH,
+		"en;q=0, zh-CN;q=0.1, *":    LanguageZH,
+		"EN":                        LanguageEN,
+		"ja, en;q=0.5, zh-CN;q=0.4": LanguageEN,
+	} {
+		DefaultTester.Get(t, RequestConfig{
+			Route:          "/language",
+			RequestHeaders: map[string]string{fiber.HeaderAcceptLanguage: header},
+			ExpectedBody:   expected,
+		})
+	}
+
+	// languages with the same primary tag are matched in order of registration
+	RegisterMessage("zh-TW", "required", "{field}不能為空")
+	defer func() {
+		messagesMu.Lock()
+		defer messagesMu.Unlock()
+		delete(messages, "zh-TW")
+		languages = languages[:len(languages)-1]
+	}()
+	for i := 0; i < 10; i++ {
+		DefaultTester.Get(t, RequestConfig{
+			Route:          "/language",
+			RequestHeaders: map[string]string{fiber.HeaderAcceptLanguage: "zh-HK, zh-TW;q=0.5"},
+			ExpectedBody:   LanguageZH,
+		})
+	}
+
+	var httpError HttpError
+	DefaultTester.Post(t, RequestConfig{
+		Route:          "/floors",
+		RequestBody:    Map{"content": "abcdef"},
+		RequestHeaders: map[string]string{fiber.HeaderAcceptLanguage: "en-US"},
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.EqualValues(t, "content must be at most 5 characters long", httpError.Message)
+	assert.EqualValues(t, "content must be at most 5 characters long", (*httpError.Detail)[0].Message)
+
+	// the message of HttpError derived from detail is translated too
+	DefaultTester.Get(t, RequestConfig{
+		Route:          "/http-error",
+		RequestHeaders: map[string]string{fiber.HeaderAcceptLanguage: "en"},
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.EqualValues(t, "content is required", httpError.Message)
+	assert.EqualValues(t, "content is required", (*httpError.Detail)[0].Message)
+}
+
+// TestMessageCatalog checks that every built-in tag of the validator has a message in each language
+func TestMessageCatalog(t *testing.T) {
+	pkg, err := build.Import("github.com/go-playground/validator/v10", ".", build.FindOnly)
+	assert.Nil(t, err)
+	file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(pkg.Dir, "baked_in.go"), nil, 0)
+	assert.Nil(t, err)
+
+	var tags []string
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.ValueSpec)
+		if !ok || len(spec.Names) != 1 || (spec.Names[0].Name != "bakedInValidators" && spec.Names[0].Name != "bakedInAliases") {
+			return true
+		}
+		for _, element := range spec.Values[0].(*ast.CompositeLit).Elts {
+			tag, _ := strconv.Unquote(element.(*ast.KeyValueExpr).Key.(*ast.BasicLit).Value)
+			tags = append(tags, tag)
+		}
+		return false
+	})
+	assert.Greater(t, len(tags), 100)
+
+	for _, language := range []string{LanguageZH, LanguageEN} {
+		for _, tag := range tags {
+			assert.Contains(t, messages[language], tag, language)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package common
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"reflect"
+
+	"github.com/goccy/go-json"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// CursorSecret is the HMAC key to sign cursors.
+// It is random by default, so it should be set to a shared secret
+// if cursors are passed between instances or across restarts.
+var CursorSecret = func() []byte {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return secret
+}()
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is an opaque position in keyset pagination.
+// It is encoded as base64 payload and HMAC signature, the zero value means the first page.
+type Cursor struct {
+	// Values are the sort column values of the boundary row
+	Values []json.RawMessage
+
+	// Backward means fetching the rows before the boundary row
+	Backward bool
+}
+
+// cursorPayload is the json form of Cursor
+type cursorPayload struct {
+	Values   []json.RawMessage `json:"v"`
+	Backward bool              `json:"b,omitempty"`
+}
+
+func (c Cursor) IsZero() bool {
+	return len(c.Values) == 0
+}
+
+func (c Cursor) String() string {
+	text, _ := c.MarshalText()
+	return string(text)
+}
+
+func (c Cursor) MarshalText() ([]byte, error) {
+	if c.IsZero() {
+		return []byte{}, nil
+	}
+
+	payload, err := json.Marshal(cursorPayload(c))
+	if err != nil {
+		return nil, err
+	}
+
+	encoding := base64.RawURLEncoding
+	text := make([]byte, encoding.EncodedLen(len(payload)))
+	encoding.Encode(text, payload)
+	text = append(text, '.')
+	return append(text, encoding.EncodeToString(signCursor(text[:len(text)-1]))...), nil
+}
+
+// UnmarshalText decodes and verifies the cursor, so it can be parsed by ValidateQuery
+func (c *Cursor) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*c = Cursor{}
+		return nil
+	}
+
+	payload, signature, found := bytes.Cut(text, []byte{'.'})
+	if !found {
+		return ErrInvalidCursor
+	}
+
+	decodedSignature, err := base64.RawURLEncoding.DecodeString(string(signature))
+	if err != nil || !hmac.Equal(decodedSignature, signCursor(payload)) {
+		return ErrInvalidCursor
+	}
+
+	decodedPayload, err := base64.RawURLEncoding.DecodeString(string(payload))
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	var cursor cursorPayload
+	if err = json.Unmarshal(decodedPayload, &cursor); err != nil {
+		return ErrInvalidCursor
+	}
+	*c = Cursor(cursor)
+	return nil
+}
+
+func signCursor(payload []byte) []byte {
+	mac := hmac.New(sha256.New, CursorSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// SortColumn is a column to sort by in keyset pagination
+type SortColumn struct {
+	Column string // db name or field name
+	Desc   bool
+}
+
+func SortAsc(column string) SortColumn {
+	return SortColumn{Column: column}
+}
+
+func SortDesc(column string) SortColumn {
+	return SortColumn{Column: column, Desc: true}
+}
+
+// CursorPage is a page of keyset pagination.
+// NextCursor or PrevCursor is zero if there is no rows in that direction.
+type CursorPage[T any] struct {
+	Items      []T
+	NextCursor Cursor
+	PrevCursor Cursor
+}
+
+// KeysetPaginate queries a page of T after or before request.Cursor, sorted by columns.
+// The last column should be unique, e.g. primary key, to make the order total.
+//
+//	page, err := KeysetPaginate[Hole](DB.Where("division_id = ?", 1), query.CursorRequest, SortDesc("updated_at"), SortDesc("id"))
+func KeysetPaginate[T any](db *gorm.DB, request CursorRequest, columns ...SortColumn) (*CursorPage[T], error) {
+	if len(columns) == 0 {
+		return nil, errors.New("keyset paginate: no sort columns")
+	}
+
+	statement := &gorm.Statement{DB: db}
+	if err := statement.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	fields := make([]*schema.Field, len(columns))
+	for i, column := range columns {
+		fields[i] = statement.Schema.LookUpField(column.Column)
+		if fields[i] == nil {
+			return nil, errors.New("keyset paginate: unknown column " + column.Column)
+		}
+	}
+
+	cursor := request.Cursor
+	backward := cursor.Backward
+	_, limit := request.OffsetLimit()
+
+	query := db
+	if !cursor.IsZero() {
+		condition, err := keysetCondition(cursor, columns, fields)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(condition)
+	}
+
+	orderBy := clause.OrderBy{Columns: make([]clause.OrderByColumn, len(columns))}
+	for i, column := range columns {
+		orderBy.Columns[i] = clause.OrderByColumn{
+			Column: clause.Column{Table: clause.CurrentTable, Name: fields[i].DBName},
+			Desc:   column.Desc != backward,
+		}
+	}
+
+	var items []T
+	err := query.Clauses(orderBy).Limit(limit + 1).Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	page := &CursorPage[T]{Items: items}
+	if len(items) == 0 {
+		return page, nil
+	}
+	if hasMore || backward {
+		page.NextCursor, err = keysetCursor(db, &items[len(items)-1], fields, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if hasMore && backward || !backward && !cursor.IsZero() {
+		page.PrevCursor, err = keysetCursor(db, &items[0], fields, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// keysetCondition builds (a > ?) OR (a = ? AND b > ?) OR ... from the cursor
+func keysetCondition(cursor Cursor, columns []SortColumn, fields []*schema.Field) (clause.Expression, error) {
+	if len(cursor.Values) != len(columns) {
+		return nil, BadRequest(ErrInvalidCursor.Error())
+	}
+
+	values := make([]any, len(columns))
+	for i, field := range fields {
+		value := reflect.New(field.FieldType)
+		if err := json.Unmarshal(cursor.Values[i], value.Interface()); err != nil {
+			return nil, BadRequest(ErrInvalidCursor.Error())
+		}
+		values[i] = value.Elem().Interface()
+	}
+
+	or := make([]clause.Expression, len(columns))
+	for i := range columns {
+		and := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			and = append(and, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: fields[j].DBName}, Value: values[j]})
+		}
+
+		column := clause.Column{Table: clause.CurrentTable, Name: fields[i].DBName}
+		if columns[i].Desc != cursor.Backward {
+			and = append(and, clause.Lt{Column: column, Value: values[i]})
+		} else {
+			and = append(and, clause.Gt{Column: column, Value: values[i]})
+		}
+		or[i] = clause.And(and...)
+	}
+	return clause.Or(or...), nil
+}
+
+func keysetCursor[T any](db *gorm.DB, item *T, fields []*schema.Field, backward bool) (Cursor, error) {
+	cursor := Cursor{Values: make([]json.RawMessage, len(fields)), Backward: backward}
+	for i, field := range fields {
+		value, _ := field.ValueOf(db.Statement.Context, reflect.ValueOf(item).Elem())
+		data, err := json.Marshal(value)
+		if err != nil {
+			return Cursor{}, err
+		}
+		cursor.Values[i] = data
+	}
+	return cursor, nil
+}
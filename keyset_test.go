@@ -0,0 +1,94 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// newRowsDB returns a dry run db returning rows on every query, and records the last sql
+func newRowsDB[T any](t *testing.T, rows []T, sql *string, vars *[]any) *gorm.DB {
+	db := newDryRunDB(t)
+	err := db.Callback().Query().After("gorm:query").Register("test:rows", func(db *gorm.DB) {
+		*sql = db.Statement.SQL.String()
+		*vars = db.Statement.Vars
+		reflect.ValueOf(db.Statement.Dest).Elem().Set(reflect.ValueOf(rows))
+	})
+	assert.Nil(t, err)
+	return db
+}
+
+func TestCursor(t *testing.T) {
+	cursor := Cursor{Values: []json.RawMessage{json.RawMessage(`1`), json.RawMessage(`"a"`)}, Backward: true}
+	text := cursor.String()
+
+	var decoded Cursor
+	err := decoded.UnmarshalText([]byte(text))
+	assert.Nil(t, err)
+	assert.EqualValues(t, cursor, decoded)
+
+	// tampered
+	err = decoded.UnmarshalText([]byte("eyJ2IjpbMl19" + text[len("eyJ2IjpbMV19"):]))
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	err = decoded.UnmarshalText([]byte("invalid"))
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestKeysetPaginate(t *testing.T) {
+	var sql string
+	var vars []any
+	db := newRowsDB(t, []testHole{{ID: 5}, {ID: 4}, {ID: 3}}, &sql, &vars)
+
+	// first page
+	page, err := KeysetPaginate[testHole](db, CursorRequest{Size: 2}, SortDesc("id"))
+	assert.Nil(t, err)
+	assert.EqualValues(t, "SELECT * FROM `test_holes` ORDER BY `test_holes`.`id` DESC LIMIT 3", sql)
+	assert.EqualValues(t, []testHole{{ID: 5}, {ID: 4}}, page.Items)
+	assert.True(t, page.PrevCursor.IsZero())
+	assert.False(t, page.NextCursor.IsZero())
+
+	// next page
+	page, err = KeysetPaginate[testHole](db, CursorRequest{Cursor: page.NextCursor, Size: 2}, SortDesc("id"))
+	assert.Nil(t, err)
+	assert.EqualValues(t, "SELECT * FROM `test_holes` WHERE `test_holes`.`id` < ? ORDER BY `test_holes`.`id` DESC LIMIT 3", sql)
+	assert.EqualValues(t, []any{4}, vars)
+	assert.False(t, page.PrevCursor.IsZero())
+
+	// previous page, multiple columns
+	db = newRowsDB(t, []testHole{{ID: 1, Likes: 2}}, &sql, &vars)
+	page, err = KeysetPaginate[testHole](db, CursorRequest{Cursor: page.PrevCursor, Size: 2}, SortDesc("id"))
+	assert.Nil(t, err)
+	assert.EqualValues(t, "SELECT * FROM `test_holes` WHERE `test_holes`.`id` > ? ORDER BY `test_holes`.`id` LIMIT 3", sql)
+	assert.True(t, page.PrevCursor.IsZero())
+	assert.False(t, page.NextCursor.IsZero())
+
+	_, err = KeysetPaginate[testHole](db, CursorRequest{Size: 2}, SortAsc("likes"), SortDesc("id"))
+	assert.Nil(t, err)
+	_, err = KeysetPaginate[testHole](db, CursorRequest{Cursor: page.NextCursor, Size: 2}, SortAsc("likes"), SortDesc("id"))
+	assert.NotNil(t, err)
+
+	cursor := Cursor{Values: []json.RawMessage{json.RawMessage(`2`), json.RawMessage(`1`)}}
+	_, err = KeysetPaginate[testHole](db, CursorRequest{Cursor: cursor, Size: 2}, SortAsc("likes"), SortDesc("id"))
+	assert.Nil(t, err)
+	assert.EqualValues(t, "SELECT * FROM `test_holes` WHERE (`test_holes`.`likes` > ? OR (`test_holes`.`likes` = ? AND `test_holes`.`id` < ?)) ORDER BY `test_holes`.`likes`,`test_holes`.`id` DESC LIMIT 3", sql)
+}
+
+func TestCursorQuery(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/holes", func(c *fiber.Ctx) error {
+		var query CursorRequest
+		if err := ValidateQuery(c, &query); err != nil {
+			return err
+		}
+		return c.JSON(query.Cursor.Values)
+	})
+	RegisterApp(app)
+
+	cursor := Cursor{Values: []json.RawMessage{json.RawMessage(`1`)}}
+	DefaultTester.Get(t, RequestConfig{Route: "/holes?cursor=" + cursor.String(), ExpectedBody: `[1]`})
+	DefaultTester.Get(t, RequestConfig{Route: "/holes?cursor=invalid", ExpectedStatus: 400})
+}
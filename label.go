@@ -0,0 +1,106 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+)
+
+// LabelFunc resolves the human-readable label of a struct field used in messages instead of the field name,
+// it reads `label` tag by default. An empty label means the field name is used.
+//
+//	type CreateHoleRequest struct {
+//		Content string `json:"content" label:"内容" validate:"max=100"`
+//	}
+var LabelFunc = func(field reflect.StructField) string {
+	return field.Tag.Get("label")
+}
+
+func fieldLabel(field reflect.StructField) string {
+	if LabelFunc == nil {
+		return ""
+	}
+	return LabelFunc(field)
+}
+
+type namespaceSegment struct {
+	name    string
+	indices []string
+}
+
+// splitNamespace splits validator namespace like Hole.Tags[2].Extra[a.b] into segments
+func splitNamespace(namespace string) []namespaceSegment {
+	var segments []namespaceSegment
+	var segment namespaceSegment
+	var name strings.Builder
+	for i := 0; i < len(namespace); i++ {
+		switch namespace[i] {
+		case '.':
+			segment.name = name.String()
+			segments = append(segments, segment)
+			segment = namespaceSegment{}
+			name.Reset()
+		case '[':
+			end := strings.IndexByte(namespace[i:], ']')
+			if end < 0 {
+				end = len(namespace) - i
+			}
+			segment.indices = append(segment.indices, namespace[i+1:i+end])
+			i += end
+		default:
+			name.WriteByte(namespace[i])
+		}
+	}
+	segment.name = name.String()
+	return append(segments, segment)
+}
+
+// fieldSegments splits validator namespace into segments of fields. The namespace of a named struct starts with
+// the type name, which is stripped, while the namespace of an anonymous struct like `var body struct{...}` does not.
+func fieldSegments(typ reflect.Type, namespace string) []namespaceSegment {
+	segments := splitNamespace(namespace)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ != nil && typ.Name() != "" {
+		return segments[1:]
+	}
+	return segments
+}
+
+// fieldNamespace returns validator struct namespace without the leading type name, e.g. Tags[0].Name
+func fieldNamespace(typ reflect.Type, namespace string) string {
+	if len(fieldSegments(typ, namespace)) < len(splitNamespace(namespace)) {
+		_, namespace, _ = strings.Cut(namespace, ".")
+	}
+	return namespace
+}
+
+// structFieldByNamespace finds the struct field in typ by validator struct namespace
+func structFieldByNamespace(typ reflect.Type, namespace string) (reflect.StructField, bool) {
+	var field reflect.StructField
+	segments := fieldSegments(typ, namespace)
+	for _, segment := range segments {
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			return field, false
+		}
+
+		var ok bool
+		if field, ok = typ.FieldByName(segment.name); !ok {
+			return field, false
+		}
+		typ = field.Type
+		for range segment.indices {
+			for typ.Kind() == reflect.Pointer {
+				typ = typ.Elem()
+			}
+			switch typ.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				typ = typ.Elem()
+			}
+		}
+	}
+	return field, len(segments) > 0
+}
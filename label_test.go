@@ -0,0 +1,61 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testLabelRequest struct {
+	ID      int    `param:"id" label:"编号"`
+	Content string `json:"content_length" label:"内容" validate:"min=10"`
+	Tags    []struct {
+		Name string `json:"name" label:"标签名" validate:"required"`
+	} `json:"tags" validate:"dive"`
+}
+
+func TestLabel(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/holes/:id", Handle(func(_ *fiber.Ctx, req *testLabelRequest) (*testLabelRequest, error) {
+		return req, nil
+	}))
+	RegisterApp(app)
+
+	var httpError HttpError
+	DefaultTester.Post(t, RequestConfig{
+		Route:          "/holes/1",
+		RequestBody:    Map{"content_length": "a", "tags": []Map{{"name": ""}}},
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.EqualValues(t, "内容至少10字符, 标签名不能为空", httpError.Message)
+	assert.EqualValues(t, "content_length", (*httpError.Detail)[0].Field)
+	assert.EqualValues(t, "内容", (*httpError.Detail)[0].Label)
+
+	httpError = HttpError{}
+	DefaultTester.Post(t, RequestConfig{
+		Route:          "/holes/a",
+		RequestBody:    Map{"content_length": "0123456789"},
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.EqualValues(t, "编号类型不正确", httpError.Message)
+}
+
+func TestStructFieldByNamespace(t *testing.T) {
+	typ := reflect.TypeOf(&testLabelRequest{})
+	field, ok := structFieldByNamespace(typ, "testLabelRequest.Tags[0].Name")
+	assert.True(t, ok)
+	assert.EqualValues(t, "标签名", field.Tag.Get("label"))
+
+	_, ok = structFieldByNamespace(typ, "testLabelRequest.Unknown")
+	assert.False(t, ok)
+
+	assert.EqualValues(t, []namespaceSegment{
+		{name: "Hole"},
+		{name: "Extra", indices: []string{"a.b"}},
+		{name: "Tags", indices: []string{"1", "2"}},
+	}, splitNamespace("Hole.Extra[a.b].Tags[1][2]"))
+}
@@ -11,3 +11,9 @@ func Message(msg string) *MessageResponse {
 		Message: msg,
 	}
 }
+
+// WithData attaches data to the message, e.g. Message("created").WithData(hole)
+func (m *MessageResponse) WithData(data any) *MessageResponse {
+	m.Data = data
+	return m
+}
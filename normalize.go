@@ -0,0 +1,263 @@
+package common
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Modifier normalizes a string, used by `mod` tag
+type Modifier func(string) string
+
+var (
+	modifiersMu sync.RWMutex
+	modifiers   = map[string]Modifier{
+		"trim":             strings.TrimSpace,
+		"lower":            strings.ToLower,
+		"upper":            strings.ToUpper,
+		"nfkc":             norm.NFKC.String,
+		"collapse_space":   collapseSpace,
+		"strip_zero_width": stripZeroWidth,
+		"sanitize_html":    SanitizeHTML,
+	}
+)
+
+// RegisterModifier registers a modifier used by `mod` tag, or overrides a built-in one
+func RegisterModifier(name string, modifier Modifier) {
+	modifiersMu.Lock()
+	defer modifiersMu.Unlock()
+	modifiers[name] = modifier
+}
+
+// Normalize modifies string fields of v in place by `mod` tag, modifiers are applied in order.
+// String, *string, []string and Optional[string] fields are supported, nested structs are normalized recursively.
+// ValidateBody, ValidateQuery and Bind call it after parsing and before setting defaults.
+//
+// Built-in modifiers:
+//
+//	trim, lower, upper
+//	nfkc              unicode NFKC normalization, e.g. full-width ＡＢＣ to ABC
+//	collapse_space    replace consecutive whitespaces with a single space, keeping line breaks
+//	strip_zero_width  remove zero-width characters
+//	sanitize_html     remove dangerous html, see SanitizeHTML
+//
+// For example:
+//
+//	type CreateFloorRequest struct {
+//		Content string `json:"content" mod:"strip_zero_width,trim,sanitize_html" validate:"required,max_runes=15000"`
+//	}
+func Normalize(v any) {
+	value, ok := structValue(v)
+	if !ok {
+		return
+	}
+	normalizeStruct(value)
+}
+
+func normalizeStruct(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldValue := value.Field(i)
+
+		tag := field.Tag.Get("mod")
+		if tag == "" {
+			normalizeNested(fieldValue)
+			continue
+		}
+
+		var chain []Modifier
+		modifiersMu.RLock()
+		for _, name := range strings.Split(tag, ",") {
+			name = strings.TrimSpace(name)
+			modifier, ok := modifiers[name]
+			if !ok {
+				modifiersMu.RUnlock()
+				panic("modifier " + name + " is not registered")
+			}
+			chain = append(chain, modifier)
+		}
+		modifiersMu.RUnlock()
+		normalizeValue(fieldValue, chain)
+	}
+}
+
+// normalizeNested normalizes structs in a field without `mod` tag
+func normalizeNested(value reflect.Value) {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if !value.IsNil() {
+			normalizeNested(value.Elem())
+		}
+	case reflect.Struct:
+		if value.Type() != timeType && value.Type() != customTimeType {
+			normalizeStruct(value)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			normalizeNested(value.Index(i))
+		}
+	}
+}
+
+func normalizeValue(value reflect.Value, chain []Modifier) {
+	switch value.Kind() {
+	case reflect.String:
+		s := value.String()
+		for _, modifier := range chain {
+			s = modifier(s)
+		}
+		value.SetString(s)
+	case reflect.Pointer:
+		if !value.IsNil() {
+			normalizeValue(value.Elem(), chain)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			normalizeValue(value.Index(i), chain)
+		}
+	case reflect.Struct:
+		if inner, ok := optionalValue(value); ok {
+			normalizeValue(inner, chain)
+		}
+	}
+}
+
+var horizontalSpaces = regexp.MustCompile(`[^\S\n]+`)
+
+// collapseSpace replaces consecutive whitespaces in a line with a single space, and trims each line
+func collapseSpace(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(horizontalSpaces.ReplaceAllString(line, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stripZeroWidth removes zero-width spaces, joiners, word joiner and BOM.
+// Zero-width joiner between emoji is kept, for emoji sequences like 👨‍👩‍👧.
+func stripZeroWidth(s string) string {
+	runes := []rune(s)
+	var builder strings.Builder
+	for i, r := range runes {
+		switch r {
+		case '\u200b', '\u200c', '\u2060', '\ufeff', '\u180e':
+			continue
+		case '\u200d':
+			if i == 0 || i == len(runes)-1 || !unicode.Is(emojiTable, runes[i-1]) || !unicode.Is(emojiTable, runes[i+1]) {
+				continue
+			}
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+var (
+	// sanitizePolicy is the allow-list of SanitizeHTML, links are not given rel="nofollow" so that safe html is kept as is
+	sanitizePolicy = bluemonday.UGCPolicy().RequireNoFollowOnLinks(false)
+
+	// markdownLinks matches destinations of markdown links, images and link reference definitions
+	markdownLinks = regexp.MustCompile(`(\]\(\s*|(?m:^ {0,3}\[[^\]\n]+\]:[ \t]*))(<[^>\n]*>|(?:[^()\n ]|\([^()\n ]*\))+)`)
+	urlScheme     = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*$`)
+)
+
+// SanitizeHTML removes html not allowed by bluemonday.UGCPolicy in markdown or html content, e.g. script and svg
+// elements, event handler attributes and urls of schemes other than http, https and mailto.
+// Unsafe urls of markdown links and images are replaced with #.
+// Text, markdown and allowed html are kept as is, they are not escaped.
+func SanitizeHTML(s string) string {
+	var builder strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+	rawTag := ""                // the element whose content is raw text, e.g. script
+	removed := map[string]int{} // removed elements whose end tags are to be removed
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			return sanitizeMarkdownLinks(builder.String())
+		case html.TextToken:
+			switch rawTag {
+			case "":
+				builder.Write(tokenizer.Raw())
+			case "script", "style":
+			default:
+				// content of removed elements like textarea is kept as text
+				builder.WriteString(html.EscapeString(string(tokenizer.Text())))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			raw := string(tokenizer.Raw())
+			token := tokenizer.Token()
+			if tokenType == html.EndTagToken {
+				if token.Data == rawTag {
+					rawTag = ""
+				}
+				if removed[token.Data] > 0 {
+					removed[token.Data]--
+					continue
+				}
+			} else if rawTextElements[token.Data] {
+				rawTag = token.Data
+			}
+
+			// tags are sanitized one by one, and kept as is if they are allowed as a whole
+			sanitized := sanitizePolicy.Sanitize(raw)
+			switch {
+			case sanitized == token.String():
+				builder.WriteString(raw)
+			case sanitized == "" && tokenType == html.StartTagToken:
+				removed[token.Data]++
+			default:
+				builder.WriteString(sanitized)
+			}
+		}
+		// comments and doctypes are removed
+	}
+}
+
+// rawTextElements are elements whose content is raw text or RCDATA in html tokenizer
+var rawTextElements = map[string]bool{
+	"iframe": true, "noembed": true, "noframes": true, "noscript": true, "plaintext": true,
+	"script": true, "style": true, "textarea": true, "title": true, "xmp": true,
+}
+
+// sanitizeMarkdownLinks replaces unsafe urls of markdown links, images and link reference definitions with #
+func sanitizeMarkdownLinks(s string) string {
+	return markdownLinks.ReplaceAllStringFunc(s, func(link string) string {
+		match := markdownLinks.FindStringSubmatch(link)
+		if unsafeURL(match[2]) {
+			return match[1] + "#"
+		}
+		return link
+	})
+}
+
+// unsafeURL reports whether url has a scheme other than http, https and mailto, after html entities, backslash
+// escapes, whitespaces and control characters are removed like markdown renderers and browsers do
+func unsafeURL(url string) bool {
+	url = html.UnescapeString(strings.TrimSuffix(strings.TrimPrefix(url, "<"), ">"))
+	url = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '\\' || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, url)
+	scheme, _, found := strings.Cut(url, ":")
+	if !found || !urlScheme.MatchString(scheme) {
+		return false // relative urls
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https", "mailto":
+		return false
+	}
+	return true
+}
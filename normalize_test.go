@@ -0,0 +1,106 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testNormalizeRequest struct {
+	Username string   `json:"username" mod:"trim,lower,nfkc"`
+	Content  string   `json:"content" mod:"strip_zero_width,collapse_space,sanitize_html" validate:"max_runes=10"`
+	Tags     []string `json:"tags" mod:"trim,upper"`
+	Nickname *string  `json:"nickname" mod:"trim"`
+	Floors   []struct {
+		Content string `json:"content" mod:"trim"`
+	} `json:"floors"`
+	Raw   string           `json:"raw"`
+	Title Optional[string] `json:"title" mod:"trim"`
+}
+
+func TestNormalize(t *testing.T) {
+	nickname := " nick "
+	request := testNormalizeRequest{
+		Username: "  ＡＤＭＩＮ ",
+		Content:  "a\u200b  b \r\n  c\u200d",
+		Tags:     []string{" a ", "b"},
+		Nickname: &nickname,
+		Raw:      " raw ",
+		Title:    Some(" title "),
+	}
+	request.Floors = append(request.Floors, struct {
+		Content string `json:"content" mod:"trim"`
+	}{" floor "})
+	Normalize(&request)
+
+	assert.EqualValues(t, "admin", request.Username)
+	assert.EqualValues(t, "a b\nc", request.Content)
+	assert.EqualValues(t, []string{"A", "B"}, request.Tags)
+	assert.EqualValues(t, "nick", *request.Nickname)
+	assert.EqualValues(t, "floor", request.Floors[0].Content)
+	assert.EqualValues(t, " raw ", request.Raw)
+	assert.EqualValues(t, Some("title"), request.Title)
+
+	assert.EqualValues(t, "👨\u200d👩", stripZeroWidth("👨\u200d👩\u200d"))
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	for input, expected := range map[string]string{
+		"**bold** <b>ok</b>":                                "**bold** <b>ok</b>",
+		"> quote & a < b":                                   "> quote & a < b",
+		`<a href=https://example.com>a</a>`:                 `<a href=https://example.com>a</a>`,
+		"[a](https://example.com/a_(b))":                    "[a](https://example.com/a_(b))",
+		"a<script>alert(1)</script>b":                       "ab",
+		"<scr<script>ipt>alert(1)</script>":                 "ipt>alert(1)",
+		`<img src="x.png" onerror="alert(1)" alt=a>`:        `<img src="x.png" alt="a">`,
+		`<a href="javascript:alert(1)">a</a>`:               "a",
+		"<iframe src=x></iframe>text":                       "text",
+		"<textarea><img src=x onerror=alert(1)></textarea>": "&lt;img src=x onerror=alert(1)&gt;",
+		"<!-- comment -->text":                              "text",
+
+		// slash separated attributes
+		"<svg/onload=alert(1)>":          "",
+		`<img/src="x"/onerror=alert(1)>`: `<img src="x">`,
+		// entity encoded schemes
+		`<a href="jav&#x61;script:alert(1)">a</a>`: "a",
+		"[x](JaVaScRiPt&colon;alert(1))":           "[x](#)",
+		"[x](javascript\\:alert(1))":               "[x](#)",
+		"![x](data:text/html;base64,PHNjcmlwdD4=)": "![x](#)",
+		"[x](< javascript:alert(1)>)":              "[x](#)",
+		"[x]: vbscript&#58;alert(1)":               "[x]: #",
+		// control characters in schemes
+		"<a href=\"java\tscript:alert(1)\">a</a>":   "a",
+		"<a href=\"\x01javascript:alert(1)\">a</a>": "a",
+		"[x](java\tscript:alert(1))":                "[x](#)",
+		"<javascript:alert(1)>":                     "",
+	} {
+		assert.EqualValues(t, expected, SanitizeHTML(input), input)
+	}
+}
+
+func TestValidateBodyNormalize(t *testing.T) {
+	assert.Nil(t, RegisterValidations())
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/floors", func(c *fiber.Ctx) error {
+		var body testNormalizeRequest
+		if err := ValidateBody(c, &body); err != nil {
+			return err
+		}
+		return c.Status(201).SendString(body.Content)
+	})
+	RegisterApp(app)
+
+	// whitespaces do not count in length limit
+	DefaultTester.Post(t, RequestConfig{
+		Route:        "/floors",
+		RequestBody:  Map{"content": "  0123456789       "},
+		ExpectedBody: "0123456789",
+	})
+	DefaultTester.Post(t, RequestConfig{
+		Route:          "/floors",
+		RequestBody:    Map{"content": "0123456789a"},
+		ExpectedStatus: 400,
+	})
+}
@@ -0,0 +1,588 @@
+package common
+
+import (
+	"encoding"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// OpenAPI is an OpenAPI 3.1 document generated from typed handlers
+type OpenAPI struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       OpenAPIInfo          `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components OpenAPIComponents    `json:"components"`
+
+	schemaTypes map[string]reflect.Type // types of the schemas registered by SchemaOf
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem is the operations of a path, parameters of the path apply to all of its operations
+type PathItem struct {
+	Summary     string       `json:"summary,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Parameters  []*Parameter `json:"parameters,omitempty"`
+	Get         *Operation   `json:"get,omitempty"`
+	Put         *Operation   `json:"put,omitempty"`
+	Post        *Operation   `json:"post,omitempty"`
+	Delete      *Operation   `json:"delete,omitempty"`
+	Options     *Operation   `json:"options,omitempty"`
+	Head        *Operation   `json:"head,omitempty"`
+	Patch       *Operation   `json:"patch,omitempty"`
+	Trace       *Operation   `json:"trace,omitempty"`
+}
+
+// operation returns the field of operation by http method, or nil if the method is unknown
+func (item *PathItem) operation(method string) **Operation {
+	switch strings.ToUpper(method) {
+	case fiber.MethodGet:
+		return &item.Get
+	case fiber.MethodPut:
+		return &item.Put
+	case fiber.MethodPost:
+		return &item.Post
+	case fiber.MethodDelete:
+		return &item.Delete
+	case fiber.MethodOptions:
+		return &item.Options
+	case fiber.MethodHead:
+		return &item.Head
+	case fiber.MethodPatch:
+		return &item.Patch
+	case fiber.MethodTrace:
+		return &item.Trace
+	default:
+		return nil
+	}
+}
+
+type Operation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*Parameter                `json:"parameters,omitempty"`
+	RequestBody *RequestBody                `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+type Parameter struct {
+	Ref      string  `json:"$ref,omitempty"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"` // path, query, header or cookie
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Ref      string                `json:"$ref,omitempty"`
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type OpenAPIResponse struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	Schemas       map[string]*Schema      `json:"schemas"`
+	Parameters    map[string]*Parameter   `json:"parameters,omitempty"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies,omitempty"`
+}
+
+// Schema is a JSON Schema used in OpenAPI 3.1
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 SchemaType         `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// SchemaType is the type keyword of JSON Schema, a single type or a list of types like ["string", "null"]
+type SchemaType []string
+
+// Has reports whether name is one of the types
+func (t SchemaType) Has(name string) bool {
+	for _, item := range t {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (t SchemaType) String() string {
+	return strings.Join(t, ",")
+}
+
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = SchemaType{name}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// NewOpenAPI returns an empty document with HttpError schema for error responses
+func NewOpenAPI(title, version string) *OpenAPI {
+	doc := &OpenAPI{
+		OpenAPI:    "3.1.0",
+		Info:       OpenAPIInfo{Title: title, Version: version},
+		Paths:      make(map[string]*PathItem),
+		Components: OpenAPIComponents{Schemas: make(map[string]*Schema)},
+	}
+	doc.SchemaOf(reflect.TypeOf(HttpError{}))
+	return doc
+}
+
+// AddOperation adds an operation, path is in fiber style like /holes/:id
+func (doc *OpenAPI) AddOperation(method, path string, operation *Operation) {
+	path = openAPIPath(path)
+	item, ok := doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		doc.Paths[path] = item
+	}
+	if field := item.operation(method); field != nil {
+		*field = operation
+	}
+}
+
+// Serve serves the document in json at route
+func (doc *OpenAPI) Serve(router fiber.Router, route string) {
+	router.Get(route, func(c *fiber.Ctx) error {
+		return c.JSON(doc)
+	})
+}
+
+var fiberPathParam = regexp.MustCompile(`:([A-Za-z0-9_]+)\??`)
+
+// openAPIPath converts fiber path /holes/:id to /holes/{id}
+func openAPIPath(path string) string {
+	return fiberPathParam.ReplaceAllString(path, "{$1}")
+}
+
+// Route registers a typed handler by Handle and documents it in doc with status 200,
+// use NewOperation and AddOperation for other statuses.
+// The returned Operation can be modified to add summary, tags, etc.
+//
+//	Route(doc, app, "GET", "/holes/:id", GetHole).Summary = "Get a hole"
+func Route[Req, Resp any](doc *OpenAPI, router fiber.Router, method, path string, fn HandlerFunc[Req, Resp]) *Operation {
+	router.Add(method, path, Handle(fn))
+
+	if group, ok := router.(*fiber.Group); ok {
+		path = group.Prefix + path
+	}
+	operation := doc.NewOperation(reflect.TypeOf((*Req)(nil)).Elem(), reflect.TypeOf((*Resp)(nil)).Elem(), fiber.StatusOK)
+	doc.AddOperation(method, path, operation)
+	return operation
+}
+
+// NewOperation builds an operation from request and response types, the response is documented with status.
+// Fields tagged with param, query, header or cookie are parameters, others are request body.
+func (doc *OpenAPI) NewOperation(requestType, responseType reflect.Type, status int) *Operation {
+	operation := &Operation{Responses: make(map[string]*OpenAPIResponse)}
+
+	for requestType.Kind() == reflect.Pointer {
+		requestType = requestType.Elem()
+	}
+	if requestType.Kind() == reflect.Struct {
+		var hasFile bool
+		for _, field := range reflect.VisibleFields(requestType) {
+			if !field.IsExported() || field.Anonymous {
+				continue
+			}
+			if field.Type == fileHeaderType || field.Type == fileHeaderSliceType {
+				hasFile = true
+			}
+			for _, source := range bindSources {
+				name, _, _ := strings.Cut(field.Tag.Get(source.tag), ",")
+				if name == "" || name == "-" {
+					continue
+				}
+				in := source.tag
+				if in == "param" {
+					in = "path"
+				}
+				_, required := parseValidateTag(field.Tag.Get("validate"))["required"]
+				operation.Parameters = append(operation.Parameters, &Parameter{
+					Name:     name,
+					In:       in,
+					Required: required || in == "path",
+					Schema:   doc.fieldSchema(field),
+				})
+			}
+		}
+
+		body := doc.objectSchema(requestType, isBodyField)
+		if len(body.Properties) > 0 {
+			contentType := fiber.MIMEApplicationJSON
+			if hasFile {
+				contentType = fiber.MIMEMultipartForm
+			}
+			operation.RequestBody = &RequestBody{
+				Required: len(body.Required) > 0,
+				Content:  map[string]*MediaType{contentType: {Schema: body}},
+			}
+		}
+	}
+
+	response := &OpenAPIResponse{Description: utils.StatusMessage(status)}
+	if status != fiber.StatusNoContent {
+		response.Content = map[string]*MediaType{fiber.MIMEApplicationJSON: {Schema: doc.SchemaOf(responseType)}}
+	}
+	operation.Responses[strconv.Itoa(status)] = response
+	errorSchema := doc.SchemaOf(reflect.TypeOf(HttpError{}))
+	operation.Responses["400"] = &OpenAPIResponse{
+		Description: "Bad Request",
+		Content:     map[string]*MediaType{fiber.MIMEApplicationJSON: {Schema: errorSchema}},
+	}
+	operation.Responses["default"] = &OpenAPIResponse{
+		Description: "Error",
+		Content:     map[string]*MediaType{fiber.MIMEApplicationJSON: {Schema: errorSchema}},
+	}
+	return operation
+}
+
+// isBodyField reports whether the field is bound from body by Bind
+func isBodyField(field reflect.StructField) bool {
+	for _, source := range bindSources {
+		if _, ok := field.Tag.Lookup(source.tag); ok {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	customTimeType     = reflect.TypeOf(CustomTime{})
+	textMarshalerType  = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	schemaNameReplacer = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// schemaName returns a valid component name of the type, including generic types
+func schemaName(typ reflect.Type) string {
+	name := typ.Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		// Response[github.com/a/b.Hole] -> Response_Hole
+		args := strings.Split(strings.TrimSuffix(name[i+1:], "]"), ",")
+		for j, arg := range args {
+			args[j] = arg[strings.LastIndexByte(arg, '.')+1:]
+		}
+		name = name[:i] + "_" + strings.Join(args, "_")
+	}
+	return schemaNameReplacer.ReplaceAllString(name, "_")
+}
+
+// componentName returns the component name of typ, ok reports whether the schema of typ is registered.
+// The name is qualified by the package if another type has the same name, e.g. models_Hole.
+func (doc *OpenAPI) componentName(typ reflect.Type) (name string, ok bool) {
+	if doc.schemaTypes == nil {
+		doc.schemaTypes = make(map[string]reflect.Type)
+	}
+	short := schemaName(typ)
+	for _, name = range []string{
+		short,
+		schemaNameReplacer.ReplaceAllString(path.Base(typ.PkgPath()), "_") + "_" + short,
+		schemaNameReplacer.ReplaceAllString(typ.PkgPath()+"."+typ.Name(), "_"),
+	} {
+		if registered, exists := doc.schemaTypes[name]; exists && registered == typ {
+			return name, true
+		}
+		if _, exists := doc.Components.Schemas[name]; !exists {
+			doc.schemaTypes[name] = typ
+			return name, false
+		}
+	}
+	panic(fmt.Sprintf("openapi: schema name of %s is used by another type", typ))
+}
+
+// nullableSchema returns schema which also allows null, e.g. type: [string, "null"]
+func nullableSchema(schema *Schema) *Schema {
+	switch {
+	case schema.Ref != "":
+		return &Schema{AnyOf: []*Schema{schema, {Type: SchemaType{"null"}}}}
+	case len(schema.Type) == 0 || schema.Type.Has("null"):
+		// no type allows null already
+		return schema
+	default:
+		schema.Type = append(schema.Type, "null")
+		return schema
+	}
+}
+
+// SchemaOf returns the schema of typ, named structs are registered in components and referenced.
+// Optional[T] is the schema of T which allows null.
+func (doc *OpenAPI) SchemaOf(typ reflect.Type) *Schema {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if valueType, ok := optionalValueType(typ); ok {
+		return nullableSchema(doc.SchemaOf(valueType))
+	}
+
+	switch {
+	case typ == customTimeType && (TimeOutputLayout == TimeLayoutUnix || TimeOutputLayout == TimeLayoutUnixMilli):
+		return &Schema{Type: SchemaType{"integer"}, Format: "int64"}
+	case typ == timeType || typ == customTimeType:
+		return &Schema{Type: SchemaType{"string"}, Format: "date-time"}
+	case typ == fileHeaderType.Elem():
+		return &Schema{Type: SchemaType{"string"}, Format: "binary"}
+	case typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array &&
+		(typ.Implements(textMarshalerType) || reflect.PointerTo(typ).Implements(textMarshalerType)):
+		return &Schema{Type: SchemaType{"string"}}
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: SchemaType{"boolean"}}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: SchemaType{"integer"}, Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: SchemaType{"integer"}, Format: "int32"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		minimum := 0.0
+		return &Schema{Type: SchemaType{"integer"}, Minimum: &minimum}
+	case reflect.Float32:
+		return &Schema{Type: SchemaType{"number"}, Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: SchemaType{"number"}, Format: "double"}
+	case reflect.String:
+		return &Schema{Type: SchemaType{"string"}}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: SchemaType{"string"}, Format: "byte"}
+		}
+		return &Schema{Type: SchemaType{"array"}, Items: doc.SchemaOf(typ.Elem())}
+	case reflect.Map:
+		return &Schema{Type: SchemaType{"object"}, AdditionalProperties: doc.SchemaOf(typ.Elem())}
+	case reflect.Struct:
+		if typ.Name() == "" {
+			return doc.objectSchema(typ, nil)
+		}
+		name, ok := doc.componentName(typ)
+		ref := &Schema{Ref: "#/components/schemas/" + name}
+		if !ok {
+			// placeholder for recursive types
+			doc.Components.Schemas[name] = &Schema{}
+			*doc.Components.Schemas[name] = *doc.objectSchema(typ, nil)
+		}
+		return ref
+	default:
+		return &Schema{}
+	}
+}
+
+// objectSchema returns the inline object schema of a struct type, with fields filtered by include
+func (doc *OpenAPI) objectSchema(typ reflect.Type, include func(field reflect.StructField) bool) *Schema {
+	schema := &Schema{Type: SchemaType{"object"}, Properties: make(map[string]*Schema)}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Tag.Get("json") == "-" || (include != nil && !include(field)) {
+			continue
+		}
+		name := jsonTagName(field)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded := doc.objectSchema(fieldType, include)
+			for embeddedName, property := range embedded.Properties {
+				if _, ok := schema.Properties[embeddedName]; !ok {
+					schema.Properties[embeddedName] = property
+				}
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = doc.fieldSchema(field)
+		if _, ok := parseValidateTag(field.Tag.Get("validate"))["required"]; ok {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// fieldSchema returns the schema of a struct field, with `validate` and `default` tags applied
+func (doc *OpenAPI) fieldSchema(field reflect.StructField) *Schema {
+	schema := *doc.SchemaOf(field.Type)
+
+	for rule, param := range parseValidateTag(field.Tag.Get("validate")) {
+		applyValidateRule(&schema, rule, param)
+	}
+
+	if value, ok := field.Tag.Lookup("default"); ok && value != "-" {
+		schema.Default = parseSchemaValue(schema.Type, value)
+	}
+	return &schema
+}
+
+// parseValidateTag parses top level rules of validate tag into map[rule]param,
+// rules after dive and alternatives with "|" are ignored
+func parseValidateTag(tag string) map[string]string {
+	rules := make(map[string]string)
+	if tag == "" {
+		return rules
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "dive" || rule == "keys" {
+			break
+		}
+		if strings.Contains(rule, "|") {
+			continue
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		rules[name] = param
+	}
+	return rules
+}
+
+func parseSchemaValue(schemaType SchemaType, value string) any {
+	switch {
+	case schemaType.Has("integer"):
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case schemaType.Has("number"):
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case schemaType.Has("boolean"):
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// applyValidateRule converts a go-playground validator rule into json schema keywords
+func applyValidateRule(schema *Schema, rule, param string) {
+	number, numberErr := strconv.ParseFloat(param, 64)
+	size, sizeErr := strconv.Atoi(param)
+
+	switch rule {
+	case "min", "max", "len":
+		switch {
+		case schema.Type.Has("string") && sizeErr == nil:
+			if rule != "max" {
+				schema.MinLength = &size
+			}
+			if rule != "min" {
+				schema.MaxLength = &size
+			}
+		case schema.Type.Has("array") && sizeErr == nil:
+			if rule != "max" {
+				schema.MinItems = &size
+			}
+			if rule != "min" {
+				schema.MaxItems = &size
+			}
+		case (schema.Type.Has("integer") || schema.Type.Has("number")) && numberErr == nil:
+			if rule != "max" {
+				schema.Minimum = &number
+			}
+			if rule != "min" {
+				schema.Maximum = &number
+			}
+		}
+	case "gte":
+		applyValidateRule(schema, "min", param)
+	case "lte":
+		applyValidateRule(schema, "max", param)
+	case "gt":
+		if numberErr == nil && (schema.Type.Has("integer") || schema.Type.Has("number")) {
+			schema.ExclusiveMinimum = &number
+		}
+	case "lt":
+		if numberErr == nil && (schema.Type.Has("integer") || schema.Type.Has("number")) {
+			schema.ExclusiveMaximum = &number
+		}
+	case "oneof":
+		for _, value := range strings.Fields(param) {
+			schema.Enum = append(schema.Enum, parseSchemaValue(schema.Type, value))
+		}
+	case "email":
+		schema.Format = "email"
+	case "url", "uri":
+		schema.Format = "uri"
+	case "uuid", "uuid4":
+		schema.Format = "uuid"
+	case "datetime":
+		// only layouts of the formats, other layouts are not expressible in json schema
+		switch param {
+		case time.RFC3339, time.RFC3339Nano, "2006-01-02T15:04:05.000Z07:00":
+			schema.Format = "date-time"
+		case time.DateOnly:
+			schema.Format = "date"
+		}
+	case "ipv4", "ipv6", "hostname":
+		schema.Format = rule
+	case "hexcolor":
+		schema.Pattern = `^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`
+	case "hex_color":
+		schema.Pattern = hexColorPattern.String()
+	case "cn_mobile":
+		schema.Pattern = cnMobilePattern.String()
+	case "campus_email":
+		schema.Format = "email"
+	case "min_runes":
+		applyValidateRule(schema, "min", param)
+	case "max_runes":
+		applyValidateRule(schema, "max", param)
+	}
+}
@@ -0,0 +1,120 @@
+package common
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testListHolesRequest struct {
+	DivisionID int    `param:"division_id"`
+	Size       int    `query:"size" default:"10" validate:"min=1,max=30"`
+	Order      string `query:"order" default:"id" validate:"oneof=id time"`
+	Token      string `header:"Authorization" validate:"required"`
+}
+
+type testUpdateHoleRequest struct {
+	ID      int      `param:"id" validate:"min=1"`
+	Content string   `json:"content" validate:"required,max=100"`
+	Tags    []string `json:"tags" validate:"max=5,dive,min=1"`
+	Email   string   `json:"email,omitempty" validate:"omitempty,email"`
+	Hole    *testOpenAPIHole
+}
+
+type testOpenAPIHole struct {
+	ID       int                `json:"id"`
+	Children []*testOpenAPIHole `json:"children"`
+	Time     CustomTime         `json:"time"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	doc := NewOpenAPI("test", "1.0.0")
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	api := app.Group("/api")
+	Route(doc, api, fiber.MethodGet, "/divisions/:division_id/holes", func(_ *fiber.Ctx, req *testListHolesRequest) (*[]testOpenAPIHole, error) {
+		return &[]testOpenAPIHole{}, nil
+	}).Summary = "List holes"
+	Route(doc, api, fiber.MethodPut, "/holes/:id", func(_ *fiber.Ctx, req *testUpdateHoleRequest) (*Response[testOpenAPIHole], error) {
+		return &Response[testOpenAPIHole]{}, nil
+	})
+	doc.Serve(app, "/openapi.json")
+	RegisterApp(app)
+
+	// list operation
+	operation := doc.Paths["/api/divisions/{division_id}/holes"].Get
+	assert.Equal(t, "List holes", operation.Summary)
+	assert.Nil(t, operation.RequestBody)
+	assert.Len(t, operation.Parameters, 4)
+	parameters := make(map[string]*Parameter)
+	for _, parameter := range operation.Parameters {
+		parameters[parameter.Name] = parameter
+	}
+	assert.Equal(t, "path", parameters["division_id"].In)
+	assert.True(t, parameters["division_id"].Required)
+	assert.Equal(t, SchemaType{"integer"}, parameters["size"].Schema.Type)
+	assert.EqualValues(t, 10, parameters["size"].Schema.Default)
+	assert.EqualValues(t, 1, *parameters["size"].Schema.Minimum)
+	assert.EqualValues(t, 30, *parameters["size"].Schema.Maximum)
+	assert.Equal(t, []any{"id", "time"}, parameters["order"].Schema.Enum)
+	assert.False(t, parameters["order"].Required)
+	assert.Equal(t, "header", parameters["Authorization"].In)
+	assert.True(t, parameters["Authorization"].Required)
+	assert.Equal(t, SchemaType{"array"}, operation.Responses["200"].Content[fiber.MIMEApplicationJSON].Schema.Type)
+	assert.Equal(t, "#/components/schemas/HttpError", operation.Responses["400"].Content[fiber.MIMEApplicationJSON].Schema.Ref)
+
+	// update operation
+	operation = doc.Paths["/api/holes/{id}"].Put
+	assert.Len(t, operation.Parameters, 1)
+	body := operation.RequestBody.Content[fiber.MIMEApplicationJSON].Schema
+	assert.True(t, operation.RequestBody.Required)
+	assert.Equal(t, []string{"content"}, body.Required)
+	assert.NotContains(t, body.Properties, "id")
+	assert.EqualValues(t, 100, *body.Properties["content"].MaxLength)
+	assert.EqualValues(t, 5, *body.Properties["tags"].MaxItems)
+	assert.Equal(t, "email", body.Properties["email"].Format)
+	assert.Equal(t, "#/components/schemas/testOpenAPIHole", body.Properties["Hole"].Ref)
+	assert.Equal(t, "#/components/schemas/Response_testOpenAPIHole", operation.Responses["200"].Content[fiber.MIMEApplicationJSON].Schema.Ref)
+
+	// components
+	hole := doc.Components.Schemas["testOpenAPIHole"]
+	assert.Equal(t, "#/components/schemas/testOpenAPIHole", hole.Properties["children"].Items.Ref)
+	assert.Equal(t, "date-time", hole.Properties["time"].Format)
+	detail := doc.Components.Schemas["HttpError"].Properties["detail"]
+	assert.Equal(t, "#/components/schemas/ErrorDetailElement", detail.Items.Ref)
+	assert.NotContains(t, doc.Components.Schemas["ErrorDetailElement"].Properties, "Kind")
+
+	// serve
+	var served OpenAPI
+	DefaultTester.Get(t, RequestConfig{Route: "/openapi.json", ResponseModel: &served})
+	assert.Equal(t, "3.1.0", served.OpenAPI)
+	assert.Len(t, served.Paths, 2)
+}
+
+func TestSchemaName(t *testing.T) {
+	assert.Equal(t, "PageResponse_testFloor", schemaName(reflect.TypeOf(PageResponse[testFloor]{})))
+	assert.Equal(t, "HttpError", schemaName(reflect.TypeOf(HttpError{})))
+}
+
+func TestSchemaNameCollision(t *testing.T) {
+	doc := NewOpenAPI("test", "1.0.0")
+	assert.Equal(t, "#/components/schemas/Decoder", doc.SchemaOf(reflect.TypeOf(xml.Decoder{})).Ref)
+	assert.Equal(t, "#/components/schemas/json_Decoder", doc.SchemaOf(reflect.TypeOf(json.Decoder{})).Ref)
+	assert.Equal(t, "#/components/schemas/Decoder", doc.SchemaOf(reflect.TypeOf(&xml.Decoder{})).Ref)
+}
+
+func TestSchemaDatetime(t *testing.T) {
+	doc := NewOpenAPI("test", "1.0.0")
+	schema := doc.SchemaOf(reflect.TypeOf(struct {
+		Time  string `json:"time" validate:"datetime=2006-01-02T15:04:05Z07:00"`
+		Date  string `json:"date" validate:"datetime=2006-01-02"`
+		Clock string `json:"clock" validate:"datetime=15:04"`
+	}{}))
+	assert.Equal(t, "date-time", schema.Properties["time"].Format)
+	assert.Equal(t, "date", schema.Properties["date"].Format)
+	assert.Equal(t, "", schema.Properties["clock"].Format)
+}
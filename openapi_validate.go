@@ -0,0 +1,558 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadOpenAPI loads an OpenAPI 3 document from a json or yaml file
+func LoadOpenAPI(file string) (*OpenAPI, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		var data any
+		if err = yaml.Unmarshal(content, &data); err != nil {
+			return nil, err
+		}
+		if content, err = json.Marshal(data); err != nil {
+			return nil, err
+		}
+	}
+
+	var doc OpenAPI
+	if err = json.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Components.Schemas == nil {
+		doc.Components.Schemas = make(map[string]*Schema)
+	}
+	if _, err = doc.check(); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// check reports $ref not found in components, invalid patterns and keywords not supported by
+// MiddlewareOpenAPIValidator, so that they are not ignored silently. It returns the compiled patterns.
+func (doc *OpenAPI) check() (map[string]*regexp.Regexp, error) {
+	checked := make(map[*Schema]bool)
+	patterns := make(map[string]*regexp.Regexp)
+	var checkSchema func(schema *Schema, location string) error
+	checkSchema = func(schema *Schema, location string) error {
+		if schema == nil || checked[schema] {
+			return nil
+		}
+		checked[schema] = true
+		if schema.Ref != "" {
+			resolved, ok := doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+			if !ok || !strings.HasPrefix(schema.Ref, "#/components/schemas/") {
+				return fmt.Errorf("openapi: %s: $ref %s not found", location, schema.Ref)
+			}
+			return checkSchema(resolved, schema.Ref)
+		}
+		if len(schema.OneOf) > 0 {
+			return fmt.Errorf("openapi: %s: oneOf is not supported", location)
+		}
+		if _, ok := patterns[schema.Pattern]; schema.Pattern != "" && !ok {
+			pattern, err := regexp.Compile(schema.Pattern)
+			if err != nil {
+				return fmt.Errorf("openapi: %s: invalid pattern %s: %w", location, schema.Pattern, err)
+			}
+			patterns[schema.Pattern] = pattern
+		}
+		children := append(append([]*Schema{schema.Items, schema.AdditionalProperties}, schema.AllOf...), schema.AnyOf...)
+		for _, property := range schema.Properties {
+			children = append(children, property)
+		}
+		for _, child := range children {
+			if err := checkSchema(child, location); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	checkParameters := func(parameters []*Parameter, location string) error {
+		for _, parameter := range parameters {
+			resolved, ok := doc.resolveParameter(parameter)
+			if !ok {
+				return fmt.Errorf("openapi: %s: $ref %s not found", location, parameter.Ref)
+			}
+			if err := checkSchema(resolved.Schema, location+" parameter "+resolved.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for path, item := range doc.Paths {
+		if err := checkParameters(item.Parameters, path); err != nil {
+			return nil, err
+		}
+		for _, method := range openAPIMethods {
+			operation := *item.operation(method)
+			if operation == nil {
+				continue
+			}
+			location := method + " " + path
+			if err := checkParameters(operation.Parameters, location); err != nil {
+				return nil, err
+			}
+			if operation.RequestBody == nil {
+				continue
+			}
+			requestBody, ok := doc.resolveRequestBody(operation.RequestBody)
+			if !ok {
+				return nil, fmt.Errorf("openapi: %s: $ref %s not found", location, operation.RequestBody.Ref)
+			}
+			for _, content := range requestBody.Content {
+				if err := checkSchema(content.Schema, location+" requestBody"); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return patterns, nil
+}
+
+var openAPIMethods = []string{
+	fiber.MethodGet, fiber.MethodPut, fiber.MethodPost, fiber.MethodDelete,
+	fiber.MethodOptions, fiber.MethodHead, fiber.MethodPatch, fiber.MethodTrace,
+}
+
+// resolveParameter follows $ref to components, ok is false if not found
+func (doc *OpenAPI) resolveParameter(parameter *Parameter) (*Parameter, bool) {
+	for i := 0; parameter != nil && parameter.Ref != "" && i < 32; i++ {
+		parameter = doc.Components.Parameters[strings.TrimPrefix(parameter.Ref, "#/components/parameters/")]
+	}
+	return parameter, parameter != nil && parameter.Ref == ""
+}
+
+// resolveRequestBody follows $ref to components, ok is false if not found
+func (doc *OpenAPI) resolveRequestBody(requestBody *RequestBody) (*RequestBody, bool) {
+	for i := 0; requestBody != nil && requestBody.Ref != "" && i < 32; i++ {
+		requestBody = doc.Components.RequestBodies[strings.TrimPrefix(requestBody.Ref, "#/components/requestBodies/")]
+	}
+	return requestBody, requestBody != nil && requestBody.Ref == ""
+}
+
+var openAPIPathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// openAPIValidator is doc with patterns compiled when the middleware is built
+type openAPIValidator struct {
+	*OpenAPI
+	patterns map[string]*regexp.Regexp
+}
+
+type openAPIRoute struct {
+	path    string
+	pattern *regexp.Regexp
+	names   []string
+	item    *PathItem
+}
+
+// MiddlewareOpenAPIValidator validates path params, query, headers, cookies and json body of requests
+// against the operations in doc before the handler runs. Requests not in doc are passed through.
+// Static paths are matched before templated ones, e.g. /holes/latest before /holes/{id}, and a request
+// is validated by the first matching path which defines its method.
+// Failures are reported as *ErrorDetail in the same shape as ValidateStruct,
+// with tags named after the validator tags, e.g. minLength is reported as min and enum as oneof.
+// It panics if doc has a $ref not found, an invalid pattern or a keyword not supported.
+func MiddlewareOpenAPIValidator(doc *OpenAPI) fiber.Handler {
+	patterns, err := doc.check()
+	if err != nil {
+		panic(err)
+	}
+	validator := &openAPIValidator{OpenAPI: doc, patterns: patterns}
+
+	var routes []openAPIRoute
+	for path, item := range doc.Paths {
+		// /holes/{id} -> ^/holes/([^/]+)/?$
+		route := openAPIRoute{path: path, item: item}
+		var pattern strings.Builder
+		var last int
+		for _, match := range openAPIPathParam.FindAllStringSubmatchIndex(path, -1) {
+			pattern.WriteString(regexp.QuoteMeta(path[last:match[0]]))
+			pattern.WriteString(`([^/]+)`)
+			route.names = append(route.names, path[match[2]:match[3]])
+			last = match[1]
+		}
+		pattern.WriteString(regexp.QuoteMeta(path[last:]))
+		route.pattern = regexp.MustCompile("^" + pattern.String() + "/?$")
+		routes = append(routes, route)
+	}
+	// paths with fewer params first, then in lexical order so that the order is stable
+	sort.Slice(routes, func(i, j int) bool {
+		if len(routes[i].names) != len(routes[j].names) {
+			return len(routes[i].names) < len(routes[j].names)
+		}
+		return routes[i].path < routes[j].path
+	})
+
+	return func(c *fiber.Ctx) error {
+		method := c.Method()
+		for _, route := range routes {
+			match := route.pattern.FindStringSubmatch(c.Path())
+			if match == nil {
+				continue
+			}
+			operation := route.item.operation(method)
+			if operation == nil || *operation == nil {
+				// another matching path may define the method
+				continue
+			}
+
+			params := make(map[string]string, len(route.names))
+			for i, name := range route.names {
+				params[name] = match[i+1]
+			}
+			if err := validator.validateRequest(c, route.item, *operation, params); err != nil {
+				return err
+			}
+			return c.Next()
+		}
+		return c.Next()
+	}
+}
+
+func (doc *openAPIValidator) validateRequest(c *fiber.Ctx, item *PathItem, operation *Operation, params map[string]string) error {
+	var errorDetail ErrorDetail
+
+	for _, parameter := range doc.operationParameters(item, operation) {
+		var values []string
+		switch parameter.In {
+		case "path":
+			values = nonEmpty(params[parameter.Name])
+		case "query":
+			for _, value := range c.Context().QueryArgs().PeekMulti(parameter.Name) {
+				values = append(values, string(value))
+			}
+		case "header":
+			values = nonEmpty(c.Get(parameter.Name))
+		case "cookie":
+			values = nonEmpty(c.Cookies(parameter.Name))
+		}
+
+		if len(values) == 0 {
+			if parameter.Required {
+				errorDetail = append(errorDetail, &ErrorDetailElement{
+					Tag:         "required",
+					Field:       parameter.Name,
+					StructField: parameter.Name,
+				})
+			}
+			continue
+		}
+
+		schema := doc.resolveSchema(parameter.Schema)
+		value, ok := parseParameterValue(schema, values)
+		if !ok {
+			errorDetail = append(errorDetail, &ErrorDetailElement{
+				Tag:         "type",
+				Field:       parameter.Name,
+				Value:       strings.Join(values, ","),
+				Param:       parameter.In,
+				StructField: parameter.Name,
+			})
+			continue
+		}
+		errorDetail = append(errorDetail, doc.validateSchema(schema, value, parameter.Name, parameter.Name)...)
+	}
+
+	if requestBody, ok := doc.resolveRequestBody(operation.RequestBody); ok {
+		body := c.Body()
+		content, jsonBody := requestBody.Content[fiber.MIMEApplicationJSON]
+		switch {
+		case len(body) == 0:
+			if requestBody.Required {
+				errorDetail = append(errorDetail, &ErrorDetailElement{Tag: "required", Field: "body", StructField: "body"})
+			}
+		case jsonBody && strings.HasSuffix(mediaType(c), "json") && content.Schema != nil:
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			var value any
+			if err := decoder.Decode(&value); err != nil {
+				return BadRequest(err.Error())
+			}
+			errorDetail = append(errorDetail, doc.validateSchema(content.Schema, value, "", "")...)
+		}
+	}
+
+	if len(errorDetail) > 0 {
+		return &errorDetail
+	}
+	return nil
+}
+
+// operationParameters returns parameters of the path and the operation,
+// the operation ones override those of the path with the same name and location
+func (doc *OpenAPI) operationParameters(item *PathItem, operation *Operation) []*Parameter {
+	type key struct{ name, in string }
+	var parameters []*Parameter
+	indices := make(map[key]int)
+	for _, parameter := range append(append([]*Parameter{}, item.Parameters...), operation.Parameters...) {
+		parameter, ok := doc.resolveParameter(parameter)
+		if !ok {
+			continue
+		}
+		if i, ok := indices[key{parameter.Name, parameter.In}]; ok {
+			parameters[i] = parameter
+			continue
+		}
+		indices[key{parameter.Name, parameter.In}] = len(parameters)
+		parameters = append(parameters, parameter)
+	}
+	return parameters
+}
+
+// resolveSchema follows $ref to components
+func (doc *OpenAPI) resolveSchema(schema *Schema) *Schema {
+	for i := 0; schema != nil && schema.Ref != "" && i < 32; i++ {
+		schema = doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+// parseParameterValue converts string values of a parameter into the json value of schema type
+func parseParameterValue(schema *Schema, values []string) (any, bool) {
+	if schema == nil {
+		return values[0], true
+	}
+	if schema.Type.Has("array") {
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		items := make([]any, 0, len(values))
+		for _, value := range values {
+			item, ok := parseParameterValue(schema.Items, []string{value})
+			if !ok {
+				return nil, false
+			}
+			items = append(items, item)
+		}
+		return items, true
+	}
+
+	value := values[0]
+	switch {
+	case schema.Type.Has("integer") || schema.Type.Has("number"):
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, false
+		}
+		return json.Number(value), true
+	case schema.Type.Has("boolean"):
+		b, err := strconv.ParseBool(value)
+		return b, err == nil
+	default:
+		return value, true
+	}
+}
+
+var schemaFormatTags = map[string]string{
+	"email":     "email",
+	"uri":       "uri",
+	"uuid":      "uuid",
+	"date-time": "datetime=2006-01-02T15:04:05Z07:00",
+	"date":      "datetime=2006-01-02",
+	"ipv4":      "ipv4",
+	"ipv6":      "ipv6",
+	"hostname":  "hostname",
+}
+
+// validateSchema validates a json value decoded with UseNumber against schema,
+// field is the json name of value and path is its path from the root
+func (doc *openAPIValidator) validateSchema(schema *Schema, value any, field, path string) ErrorDetail {
+	schema = doc.resolveSchema(schema)
+	if schema == nil {
+		return nil
+	}
+
+	newError := func(tag, param string, kind reflect.Kind) ErrorDetail {
+		return ErrorDetail{{
+			Tag:         tag,
+			Field:       field,
+			Kind:        kind,
+			Value:       value,
+			Param:       param,
+			StructField: field,
+			Path:        path,
+			Pointer:     jsonPointer(path),
+		}}
+	}
+	// allows reports whether the value of types is allowed by type keyword
+	allows := func(types ...string) bool {
+		if len(schema.Type) == 0 {
+			return true
+		}
+		for _, name := range types {
+			if schema.Type.Has(name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// the value should be valid against all subschemas of allOf
+	var errorDetail ErrorDetail
+	for _, subschema := range schema.AllOf {
+		errorDetail = append(errorDetail, doc.validateSchema(subschema, value, field, path)...)
+	}
+
+	// the value should be valid against any subschema of anyOf, errors of the first one are reported otherwise
+	if len(schema.AnyOf) > 0 {
+		var anyOfErrors ErrorDetail
+		for i, subschema := range schema.AnyOf {
+			subschemaErrors := doc.validateSchema(subschema, value, field, path)
+			if len(subschemaErrors) == 0 {
+				anyOfErrors = nil
+				break
+			}
+			if i == 0 {
+				anyOfErrors = subschemaErrors
+			}
+		}
+		errorDetail = append(errorDetail, anyOfErrors...)
+	}
+
+	if value == nil {
+		if allows("null") {
+			return errorDetail
+		}
+		return append(errorDetail, newError("type", schema.Type.String(), reflect.Invalid)...)
+	}
+
+	if len(schema.Enum) > 0 {
+		var found bool
+		params := make([]string, len(schema.Enum))
+		for i, item := range schema.Enum {
+			params[i] = fmt.Sprint(item)
+			if params[i] == fmt.Sprint(value) {
+				found = true
+			}
+		}
+		if !found {
+			errorDetail = append(errorDetail, newError("oneof", strings.Join(params, " "), reflect.String)...)
+		}
+	}
+
+	switch value := value.(type) {
+	case string:
+		if !allows("string") {
+			return append(errorDetail, newError("type", schema.Type.String(), reflect.String)...)
+		}
+		length := len([]rune(value))
+		if schema.MinLength != nil && length < *schema.MinLength {
+			errorDetail = append(errorDetail, newError("min", strconv.Itoa(*schema.MinLength), reflect.String)...)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			errorDetail = append(errorDetail, newError("max", strconv.Itoa(*schema.MaxLength), reflect.String)...)
+		}
+		if schema.Pattern != "" {
+			if pattern, ok := doc.patterns[schema.Pattern]; ok && !pattern.MatchString(value) {
+				errorDetail = append(errorDetail, newError("pattern", schema.Pattern, reflect.String)...)
+			}
+		}
+		if tag, ok := schemaFormatTags[schema.Format]; ok && Validate.Var(value, tag) != nil {
+			name, param, _ := strings.Cut(tag, "=")
+			errorDetail = append(errorDetail, newError(name, param, reflect.String)...)
+		}
+	case json.Number:
+		if !allows("number", "integer") {
+			return append(errorDetail, newError("type", schema.Type.String(), reflect.Float64)...)
+		}
+		if !allows("number") {
+			if _, err := value.Int64(); err != nil {
+				return append(errorDetail, newError("type", schema.Type.String(), reflect.Int)...)
+			}
+		}
+		number, _ := value.Float64()
+		bounds := []struct {
+			tag   string
+			bound *float64
+			fail  bool
+		}{
+			{"min", schema.Minimum, schema.Minimum != nil && number < *schema.Minimum},
+			{"max", schema.Maximum, schema.Maximum != nil && number > *schema.Maximum},
+			{"gt", schema.ExclusiveMinimum, schema.ExclusiveMinimum != nil && number <= *schema.ExclusiveMinimum},
+			{"lt", schema.ExclusiveMaximum, schema.ExclusiveMaximum != nil && number >= *schema.ExclusiveMaximum},
+		}
+		for _, bound := range bounds {
+			if bound.fail {
+				errorDetail = append(errorDetail, newError(bound.tag, strconv.FormatFloat(*bound.bound, 'f', -1, 64), reflect.Float64)...)
+			}
+		}
+	case bool:
+		if !allows("boolean") {
+			return append(errorDetail, newError("type", schema.Type.String(), reflect.Bool)...)
+		}
+	case []any:
+		if !allows("array") {
+			return append(errorDetail, newError("type", schema.Type.String(), reflect.Slice)...)
+		}
+		if schema.MinItems != nil && len(value) < *schema.MinItems {
+			errorDetail = append(errorDetail, newError("min", strconv.Itoa(*schema.MinItems), reflect.Slice)...)
+		}
+		if schema.MaxItems != nil && len(value) > *schema.MaxItems {
+			errorDetail = append(errorDetail, newError("max", strconv.Itoa(*schema.MaxItems), reflect.Slice)...)
+		}
+		for i, item := range value {
+			index := "[" + strconv.Itoa(i) + "]"
+			errorDetail = append(errorDetail, doc.validateSchema(schema.Items, item, field+index, path+index)...)
+		}
+	case map[string]any:
+		if !allows("object") {
+			return append(errorDetail, newError("type", schema.Type.String(), reflect.Map)...)
+		}
+		for _, name := range schema.Required {
+			if _, ok := value[name]; !ok {
+				errorDetail = append(errorDetail, &ErrorDetailElement{
+					Tag:         "required",
+					Field:       name,
+					StructField: name,
+					Path:        joinFieldPath(path, name),
+					Pointer:     jsonPointer(joinFieldPath(path, name)),
+				})
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			item := value[name]
+			property, ok := schema.Properties[name]
+			if !ok {
+				property = schema.AdditionalProperties
+			}
+			errorDetail = append(errorDetail, doc.validateSchema(property, item, name, joinFieldPath(path, name))...)
+		}
+	}
+	return errorDetail
+}
+
+// joinFieldPath appends the property name to path, names which are not identifiers are quoted, e.g. a["b.c"]
+func joinFieldPath(path, name string) string {
+	if !identifierPattern.MatchString(name) {
+		return path + "[" + strconv.Quote(name) + "]"
+	}
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,274 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const testOpenAPIDocument = `
+openapi: 3.1.0
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /holes/{id}:
+    put:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+            minimum: 1
+        - name: tags
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+              enum: [a, b]
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Hole'
+      responses:
+        "200":
+          description: OK
+components:
+  schemas:
+    Hole:
+      type: object
+      required: [content]
+      properties:
+        content:
+          type: string
+          maxLength: 5
+        email:
+          type: string
+          format: email
+        floors:
+          type: array
+          items:
+            type: object
+            properties:
+              likes:
+                type: integer
+`
+
+func TestMiddlewareOpenAPIValidator(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "openapi.yaml")
+	assert.Nil(t, os.WriteFile(file, []byte(testOpenAPIDocument), 0644))
+	doc, err := LoadOpenAPI(file)
+	assert.Nil(t, err)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(MiddlewareOpenAPIValidator(doc))
+	app.Put("/holes/:id", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	app.Get("/holes/:id", func(c *fiber.Ctx) error {
+		return c.SendString("not documented")
+	})
+	RegisterApp(app)
+
+	DefaultTester.Put(t, RequestConfig{Route: "/holes/1?tags=a,b", RequestBody: Map{"content": "a"}, ExpectedBody: "ok"})
+	DefaultTester.Get(t, RequestConfig{Route: "/holes/0", ExpectedBody: "not documented"})
+
+	var httpError HttpError
+	DefaultTester.Put(t, RequestConfig{
+		Route:          "/holes/0?tags=c",
+		RequestBody:    Map{"content": "abcdef", "email": "a", "floors": []Map{{"likes": "1"}}},
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	tags := make(map[string]string)
+	for _, element := range *httpError.Detail {
+		tags[element.Path] = element.Tag
+	}
+	assert.Equal(t, map[string]string{
+		"id":              "min",
+		"tags[0]":         "oneof",
+		"content":         "max",
+		"email":           "email",
+		"floors[0].likes": "type",
+	}, tags)
+	// field is the json name, with the path in path and pointer
+	element := (*httpError.Detail)[len(*httpError.Detail)-1]
+	assert.Equal(t, "likes", element.Field)
+	assert.Equal(t, "/floors/0/likes", element.Pointer)
+	assert.Equal(t, "likes类型不正确", element.Message)
+
+	httpError = HttpError{}
+	DefaultTester.Put(t, RequestConfig{Route: "/holes/a", RequestBody: Map{}, ExpectedStatus: 400, ResponseModel: &httpError})
+	assert.Len(t, *httpError.Detail, 2)
+	assert.Equal(t, "content不能为空", (*httpError.Detail)[1].Message)
+}
+
+func TestMiddlewareOpenAPIValidatorCodeFirst(t *testing.T) {
+	doc := NewOpenAPI("test", "1.0.0")
+	doc.AddOperation(fiber.MethodPost, "/floors", doc.NewOperation(
+		reflect.TypeOf(testCreateFloorRequest{}), reflect.TypeOf(testFloor{}), fiber.StatusCreated,
+	))
+	assert.Equal(t, "Created", doc.Paths["/floors"].Post.Responses["201"].Description)
+	var patchRequest struct {
+		Content Optional[string]    `json:"content" validate:"omitempty,max=5"`
+		Floor   Optional[testFloor] `json:"floor"`
+	}
+	doc.AddOperation(fiber.MethodPatch, "/floors/1", doc.NewOperation(
+		reflect.TypeOf(patchRequest), reflect.TypeOf(testFloor{}), fiber.StatusOK,
+	))
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(MiddlewareOpenAPIValidator(doc))
+	app.Post("/floors", func(c *fiber.Ctx) error {
+		return c.Status(201).SendString("ok")
+	})
+	app.Patch("/floors/1", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	RegisterApp(app)
+
+	DefaultTester.Post(t, RequestConfig{Route: "/floors", RequestBody: Map{"content": "a"}, ExpectedBody: "ok"})
+	DefaultTester.Post(t, RequestConfig{Route: "/floors", RequestBody: Map{"content": 1}, ExpectedStatus: 400})
+
+	// Optional fields allow null
+	DefaultTester.Patch(t, RequestConfig{Route: "/floors/1", RequestBody: Map{"content": nil, "floor": nil}, ExpectedBody: "ok"})
+	DefaultTester.Patch(t, RequestConfig{Route: "/floors/1", RequestBody: Map{"floor": Map{"id": 1}}, ExpectedBody: "ok"})
+	DefaultTester.Patch(t, RequestConfig{Route: "/floors/1", RequestBody: Map{"content": "abcdef"}, ExpectedStatus: 400})
+	DefaultTester.Patch(t, RequestConfig{Route: "/floors/1", RequestBody: Map{"floor": 1}, ExpectedStatus: 400})
+}
+
+func TestMiddlewareOpenAPIValidatorRouteOrder(t *testing.T) {
+	minimum := 1.0
+	doc := NewOpenAPI("test", "1.0.0")
+	doc.AddOperation(fiber.MethodGet, "/holes/:id", &Operation{Parameters: []*Parameter{
+		{Name: "id", In: "path", Required: true, Schema: &Schema{Type: SchemaType{"integer"}, Minimum: &minimum}},
+	}})
+	doc.AddOperation(fiber.MethodGet, "/holes/latest", &Operation{Parameters: []*Parameter{
+		{Name: "size", In: "query", Required: true, Schema: &Schema{Type: SchemaType{"integer"}}},
+	}})
+	doc.AddOperation(fiber.MethodPost, "/:resource/latest", &Operation{Parameters: []*Parameter{
+		{Name: "token", In: "header", Required: true, Schema: &Schema{Type: SchemaType{"string"}}},
+	}})
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(MiddlewareOpenAPIValidator(doc))
+	app.All("/*", func(c *fiber.Ctx) error {
+		if c.Method() == fiber.MethodPost {
+			c.Status(201)
+		}
+		return c.SendString("ok")
+	})
+	RegisterApp(app)
+
+	// static path is matched first, regardless of map order
+	for i := 0; i < 10; i++ {
+		DefaultTester.Get(t, RequestConfig{Route: "/holes/latest?size=1", ExpectedBody: "ok"})
+		DefaultTester.Get(t, RequestConfig{Route: "/holes/latest", ExpectedStatus: 400})
+	}
+	DefaultTester.Get(t, RequestConfig{Route: "/holes/0", ExpectedStatus: 400})
+
+	// /holes/latest has no post operation, so the templated path validates it
+	DefaultTester.Post(t, RequestConfig{Route: "/holes/latest", ExpectedStatus: 400})
+	DefaultTester.Post(t, RequestConfig{Route: "/holes/latest", RequestHeaders: map[string]string{"token": "a"}, ExpectedBody: "ok"})
+
+	// no matching path defines the method
+	DefaultTester.Delete(t, RequestConfig{Route: "/holes/latest", ExpectedBody: "ok"})
+}
+
+const testOpenAPIDocument31 = `
+openapi: 3.1.0
+info:
+  title: test
+  version: 1.0.0
+paths:
+  /holes/{id}:
+    summary: hole
+    parameters:
+      - $ref: '#/components/parameters/ID'
+    patch:
+      requestBody:
+        $ref: '#/components/requestBodies/Hole'
+      responses:
+        "200":
+          description: OK
+components:
+  parameters:
+    ID:
+      name: id
+      in: path
+      required: true
+      schema:
+        type: integer
+        minimum: 1
+  requestBodies:
+    Hole:
+      content:
+        application/json:
+          schema:
+            allOf:
+              - $ref: '#/components/schemas/Content'
+              - type: object
+                properties:
+                  tag:
+                    type: [string, "null"]
+                    maxLength: 3
+  schemas:
+    Content:
+      type: object
+      required: [content]
+      properties:
+        content:
+          type: string
+`
+
+func TestLoadOpenAPI31(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "openapi.yaml")
+	assert.Nil(t, os.WriteFile(file, []byte(testOpenAPIDocument31), 0644))
+	doc, err := LoadOpenAPI(file)
+	assert.Nil(t, err)
+	assert.Equal(t, "hole", doc.Paths["/holes/{id}"].Summary)
+	assert.Equal(t, SchemaType{"string", "null"}, doc.Components.RequestBodies["Hole"].Content[fiber.MIMEApplicationJSON].Schema.AllOf[1].Properties["tag"].Type)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(MiddlewareOpenAPIValidator(doc))
+	app.Patch("/holes/:id", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	RegisterApp(app)
+
+	DefaultTester.Patch(t, RequestConfig{Route: "/holes/1", RequestBody: Map{"content": "a", "tag": nil}, ExpectedBody: "ok"})
+
+	var httpError HttpError
+	DefaultTester.Patch(t, RequestConfig{
+		Route:          "/holes/0",
+		RequestBody:    Map{"tag": "abcd"},
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	tags := make(map[string]string)
+	for _, element := range *httpError.Detail {
+		tags[element.Field] = element.Tag
+	}
+	assert.Equal(t, map[string]string{"id": "min", "content": "required", "tag": "max"}, tags)
+
+	// unsupported keywords and missing references are rejected
+	for _, document := range []string{
+		strings.Replace(testOpenAPIDocument31, "allOf", "oneOf", 1),
+		strings.Replace(testOpenAPIDocument31, "'#/components/parameters/ID'", "'#/components/parameters/Missing'", 1),
+		strings.Replace(testOpenAPIDocument31, "'#/components/schemas/Content'", "'other.yaml#/Content'", 1),
+		strings.Replace(testOpenAPIDocument31, "maxLength: 3", "pattern: '[a-'", 1),
+	} {
+		assert.Nil(t, os.WriteFile(file, []byte(document), 0644))
+		_, err = LoadOpenAPI(file)
+		assert.NotNil(t, err)
+	}
+}
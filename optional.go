@@ -0,0 +1,125 @@
+package common
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Optional is a value that tracks whether it is present in request and whether it is null,
+// so that an explicit zero value can be told from an omitted one, e.g. in PATCH requests.
+// It is unmarshalled from json, query, form, params and headers.
+//
+// Validation tags apply to Value when it is present and not null, otherwise it is validated as a nil pointer,
+// so use `omitempty` for optional fields, and `required` for fields that must be present, not null and not zero.
+// A default value by `default` tag is only set when it is omitted, and makes it present.
+//
+//	type UpdateHoleRequest struct {
+//		Hidden  Optional[bool]   `json:"hidden"`
+//		Content Optional[string] `json:"content" validate:"omitempty,max=100"`
+//	}
+type Optional[T any] struct {
+	Value   T
+	Present bool // present in request, including null
+	Null    bool // null in json
+}
+
+// Some returns a present Optional of value
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{Value: value, Present: true}
+}
+
+// Get returns the value and whether it is present and not null
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Present && !o.Null
+}
+
+// OrElse returns the value if present and not null, otherwise value
+func (o Optional[T]) OrElse(value T) T {
+	if o.Present && !o.Null {
+		return o.Value
+	}
+	return value
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var zero T
+	o.Value, o.Present, o.Null = zero, true, string(data) == "null"
+	if o.Null {
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Present || o.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+func (o *Optional[T]) UnmarshalText(text []byte) error {
+	var zero T
+	o.Value, o.Present, o.Null = zero, true, false
+	return setValueFromString(reflect.ValueOf(&o.Value).Elem(), string(text))
+}
+
+// optional is implemented by all instantiations of Optional
+type optional interface {
+	validationValue() any
+	valueType() reflect.Type
+}
+
+func (o Optional[T]) validationValue() any {
+	if !o.Present || o.Null {
+		return nil
+	}
+	return o.Value
+}
+
+func (o Optional[T]) valueType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+var optionalType = reflect.TypeOf((*optional)(nil)).Elem()
+
+// optionalValueType returns the type of Value if typ is an Optional, typ can be nil
+func optionalValueType(typ reflect.Type) (reflect.Type, bool) {
+	if typ == nil || typ.Kind() != reflect.Struct || !typ.Implements(optionalType) {
+		return nil, false
+	}
+	return reflect.Zero(typ).Interface().(optional).valueType(), true
+}
+
+// optionalValue returns Value of value if it is a present and not null Optional
+func optionalValue(value reflect.Value) (reflect.Value, bool) {
+	if _, ok := optionalValueType(value.Type()); !ok {
+		return value, false
+	}
+	if !value.FieldByName("Present").Bool() || value.FieldByName("Null").Bool() {
+		return value, false
+	}
+	return value.FieldByName("Value"), true
+}
+
+func validateOptional(field reflect.Value) any {
+	return field.Interface().(optional).validationValue()
+}
+
+// RegisterOptionalType registers Optional[T] into Validate so that validation tags apply to its Value.
+// Optional of basic types, time and their slices are registered by default.
+func RegisterOptionalType[T any]() {
+	Validate.RegisterCustomTypeFunc(validateOptional, Optional[T]{})
+}
+
+func init() {
+	Validate.RegisterCustomTypeFunc(validateOptional,
+		Optional[string]{}, Optional[bool]{},
+		Optional[int]{}, Optional[int8]{}, Optional[int16]{}, Optional[int32]{}, Optional[int64]{},
+		Optional[uint]{}, Optional[uint8]{}, Optional[uint16]{}, Optional[uint32]{}, Optional[uint64]{},
+		Optional[float32]{}, Optional[float64]{},
+		Optional[time.Time]{}, Optional[time.Duration]{}, Optional[CustomTime]{},
+		Optional[[]string]{}, Optional[[]int]{}, Optional[[]int64]{},
+	)
+}
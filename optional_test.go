@@ -0,0 +1,106 @@
+package common
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPatchHoleRequest struct {
+	Hidden  Optional[bool]   `json:"hidden" query:"hidden"`
+	Likes   Optional[int]    `json:"likes" query:"likes" default:"10"`
+	Content Optional[string] `json:"content" validate:"omitempty,max=5"`
+	Title   Optional[string] `json:"title" validate:"required"`
+}
+
+func TestOptionalJSON(t *testing.T) {
+	var request testPatchHoleRequest
+	err := json.Unmarshal([]byte(`{"hidden":false,"content":null}`), &request)
+	assert.Nil(t, err)
+	assert.EqualValues(t, Optional[bool]{Present: true}, request.Hidden)
+	assert.EqualValues(t, Optional[string]{Present: true, Null: true}, request.Content)
+	assert.False(t, request.Likes.Present)
+
+	_, ok := request.Content.Get()
+	assert.False(t, ok)
+	assert.EqualValues(t, "a", request.Content.OrElse("a"))
+
+	data, err := json.Marshal(struct {
+		A Optional[int] `json:"a"`
+		B Optional[int] `json:"b"`
+	}{A: Some(0)})
+	assert.Nil(t, err)
+	assert.EqualValues(t, `{"a":0,"b":null}`, string(data))
+}
+
+func TestOptionalValidate(t *testing.T) {
+	err := ValidateStruct(&testPatchHoleRequest{Title: Some("a")})
+	assert.Nil(t, err)
+
+	err = ValidateStruct(&testPatchHoleRequest{Title: Optional[string]{Present: true, Null: true}, Content: Some("abcdef")})
+	assert.NotNil(t, err)
+	errorDetail := *err.(*ErrorDetail)
+	assert.Len(t, errorDetail, 2)
+	assert.EqualValues(t, "max", errorDetail[0].Tag)
+	assert.EqualValues(t, "required", errorDetail[1].Tag)
+
+	type custom struct {
+		Values Optional[[]float64] `validate:"omitempty,min=2"`
+	}
+	RegisterOptionalType[[]float64]()
+	assert.NotNil(t, ValidateStruct(&custom{Values: Some([]float64{1})}))
+	assert.Nil(t, ValidateStruct(&custom{}))
+}
+
+func TestOptionalBody(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Patch("/holes", func(c *fiber.Ctx) error {
+		var body testPatchHoleRequest
+		if err := ValidateBodyStrict(c, &body); err != nil {
+			return err
+		}
+		return c.JSON(Map{"likes": body.Likes.Value, "hidden": body.Hidden})
+	})
+	app.Get("/holes", func(c *fiber.Ctx) error {
+		var query testPatchHoleRequest
+		if err := c.QueryParser(&query); err != nil {
+			return err
+		}
+		return c.JSON(Map{"likes": query.Likes, "hidden": query.Hidden.Present})
+	})
+	RegisterApp(app)
+
+	// explicit zero is not overwritten by default
+	DefaultTester.Patch(t, RequestConfig{
+		Route:        "/holes",
+		RequestBody:  Map{"likes": 0, "title": "a"},
+		ExpectedBody: `{"hidden":null,"likes":0}`,
+	})
+	DefaultTester.Patch(t, RequestConfig{
+		Route:        "/holes",
+		RequestBody:  Map{"hidden": true, "title": "a"},
+		ExpectedBody: `{"hidden":true,"likes":10}`,
+	})
+	DefaultTester.Patch(t, RequestConfig{
+		Route:          "/holes",
+		RequestBody:    Map{"title": "a", "unknown": 1},
+		ExpectedStatus: 400,
+	})
+	DefaultTester.Get(t, RequestConfig{Route: "/holes?likes=0", ExpectedBody: `{"hidden":false,"likes":0}`})
+}
+
+func TestOptionalSchema(t *testing.T) {
+	doc := NewOpenAPI("test", "1.0.0")
+	schema := doc.SchemaOf(reflect.TypeOf(testPatchHoleRequest{}))
+	properties := doc.Components.Schemas[schema.Ref[len("#/components/schemas/"):]].Properties
+	assert.EqualValues(t, SchemaType{"boolean", "null"}, properties["hidden"].Type)
+	assert.EqualValues(t, 10, properties["likes"].Default)
+
+	// structs are referenced, with null in anyOf
+	schema = doc.SchemaOf(reflect.TypeOf(Optional[testFloor]{}))
+	assert.EqualValues(t, "#/components/schemas/testFloor", schema.AnyOf[0].Ref)
+	assert.EqualValues(t, SchemaType{"null"}, schema.AnyOf[1].Type)
+}
@@ -0,0 +1,153 @@
+package common
+
+import (
+	"encoding/xml"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// MaxPageSize caps the limit of every page request
+var MaxPageSize = 100
+
+// Pager is implemented by PageRequest and CursorRequest
+type Pager interface {
+	OffsetLimit() (offset, limit int)
+}
+
+// PageRequest is a query model supporting both offset/limit and page/size.
+// page/size takes precedence if page is set.
+type PageRequest struct {
+	Offset int `json:"offset" query:"offset" default:"0" validate:"min=0"`
+	Limit  int `json:"limit" query:"limit" default:"10" validate:"min=1"`
+	Page   int `json:"page" query:"page" validate:"min=0"`              // start from 1
+	Size   int `json:"size" query:"size" default:"10" validate:"min=1"` // page size
+}
+
+// OffsetLimit returns offset and limit of the request, limit is capped by MaxPageSize
+func (r PageRequest) OffsetLimit() (offset, limit int) {
+	if r.Page > 0 {
+		limit = Min(r.Size, MaxPageSize)
+		return (r.Page - 1) * limit, limit
+	}
+	return r.Offset, Min(r.Limit, MaxPageSize)
+}
+
+// CursorRequest is a query model of cursor based pagination
+type CursorRequest struct {
+	Cursor Cursor `json:"cursor" query:"cursor"`
+	Size   int    `json:"size" query:"size" default:"10" validate:"min=1"`
+}
+
+// OffsetLimit returns 0 and size of the request, size is capped by MaxPageSize
+func (r CursorRequest) OffsetLimit() (offset, limit int) {
+	return 0, Min(r.Size, MaxPageSize)
+}
+
+// Paginate is a gorm scope applying offset and limit of the request
+//
+//	db.Scopes(Paginate(request)).Find(&holes)
+func Paginate(pager Pager) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		offset, limit := pager.OffsetLimit()
+		if offset > 0 {
+			db = db.Offset(offset)
+		}
+		return db.Limit(limit)
+	}
+}
+
+type PageLinks struct {
+	Self string `json:"self,omitempty"`
+	Prev string `json:"prev,omitempty"`
+	Next string `json:"next,omitempty"`
+}
+
+// PageMeta is the pagination info of a response
+type PageMeta struct {
+	Total      int64     `json:"total"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	PrevCursor string    `json:"prev_cursor,omitempty"`
+	Links      PageLinks `json:"links"`
+}
+
+type PageResponse[T any] struct {
+	XMLName xml.Name `json:"-" xml:"page"`
+	Items   []T      `json:"items"`
+	PageMeta
+}
+
+// NewPageResponse returns a PageResponse of offset pagination, with links to adjacent pages
+func NewPageResponse[T any](c *fiber.Ctx, items []T, total int64, request PageRequest) *PageResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	offset, limit := request.OffsetLimit()
+	response := &PageResponse[T]{
+		Items: items,
+		PageMeta: PageMeta{
+			Total: total,
+			Links: PageLinks{Self: c.OriginalURL()},
+		},
+	}
+
+	if limit == 0 {
+		return response
+	}
+	if request.Page > 0 {
+		if request.Page > 1 {
+			response.Links.Prev = pageLink(c, "page", request.Page-1)
+		}
+		if int64(offset+limit) < total {
+			response.Links.Next = pageLink(c, "page", request.Page+1)
+		}
+	} else {
+		if offset > 0 {
+			response.Links.Prev = pageLink(c, "offset", Max(offset-limit, 0))
+		}
+		if int64(offset+limit) < total {
+			response.Links.Next = pageLink(c, "offset", offset+limit)
+		}
+	}
+	return response
+}
+
+// NewCursorPageResponse returns a PageResponse of keyset pagination, with links to adjacent pages
+func NewCursorPageResponse[T any](c *fiber.Ctx, page *CursorPage[T]) *PageResponse[T] {
+	items := page.Items
+	if items == nil {
+		items = []T{}
+	}
+
+	response := &PageResponse[T]{
+		Items: items,
+		PageMeta: PageMeta{
+			NextCursor: page.NextCursor.String(),
+			PrevCursor: page.PrevCursor.String(),
+			Links:      PageLinks{Self: c.OriginalURL()},
+		},
+	}
+	if response.NextCursor != "" {
+		response.Links.Next = pageLinkString(c, "cursor", response.NextCursor)
+	}
+	if response.PrevCursor != "" {
+		response.Links.Prev = pageLinkString(c, "cursor", response.PrevCursor)
+	}
+	return response
+}
+
+func pageLink(c *fiber.Ctx, key string, value int) string {
+	return pageLinkString(c, key, strconv.Itoa(value))
+}
+
+// pageLinkString returns the current url with the query key replaced by value
+func pageLinkString(c *fiber.Ctx, key string, value string) string {
+	args := fiber.AcquireArgs()
+	defer fiber.ReleaseArgs(args)
+
+	c.Request().URI().QueryArgs().CopyTo(args)
+	args.Set(key, value)
+	return c.Path() + "?" + args.String()
+}
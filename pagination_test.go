@@ -0,0 +1,92 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+type testHole struct {
+	ID         int    `json:"id" gorm:"primaryKey"`
+	Content    string `json:"content"`
+	Likes      int    `json:"likes"`
+	DivisionID int    `json:"division_id"`
+}
+
+func newDryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	assert.Nil(t, err)
+	return db
+}
+
+func TestPageRequest(t *testing.T) {
+	offset, limit := PageRequest{Offset: 5, Limit: 10}.OffsetLimit()
+	assert.EqualValues(t, 5, offset)
+	assert.EqualValues(t, 10, limit)
+
+	offset, limit = PageRequest{Offset: 5, Limit: 10, Page: 3, Size: 20}.OffsetLimit()
+	assert.EqualValues(t, 40, offset)
+	assert.EqualValues(t, 20, limit)
+
+	_, limit = PageRequest{Limit: 1000}.OffsetLimit()
+	assert.EqualValues(t, MaxPageSize, limit)
+}
+
+func TestPaginate(t *testing.T) {
+	db := newDryRunDB(t)
+
+	var holes []testHole
+	statement := db.Scopes(Paginate(PageRequest{Page: 2, Size: 10})).Find(&holes).Statement
+	assert.EqualValues(t, "SELECT * FROM `test_holes` LIMIT 10 OFFSET 10", statement.SQL.String())
+
+	statement = db.Scopes(Paginate(CursorRequest{Size: 5})).Find(&holes).Statement
+	assert.EqualValues(t, "SELECT * FROM `test_holes` LIMIT 5", statement.SQL.String())
+}
+
+func TestNewPageResponse(t *testing.T) {
+	app := fiber.New()
+	app.Get("/holes", func(c *fiber.Ctx) error {
+		var query PageRequest
+		if err := ValidateQuery(c, &query); err != nil {
+			return err
+		}
+		return c.JSON(NewPageResponse(c, []int{1, 2}, 30, query))
+	})
+	RegisterApp(app)
+
+	var response PageResponse[int]
+	DefaultTester.Get(t, RequestConfig{
+		Route:         "/holes",
+		RequestQuery:  PageRequest{Page: 2, Size: 10},
+		ResponseModel: &response,
+	})
+	assert.EqualValues(t, 30, response.Total)
+	assert.EqualValues(t, "/holes?page=1&size=10", response.Links.Prev)
+	assert.EqualValues(t, "/holes?page=3&size=10", response.Links.Next)
+
+	response = PageResponse[int]{}
+	DefaultTester.Get(t, RequestConfig{
+		Route:         "/holes?offset=20&limit=10",
+		ResponseModel: &response,
+	})
+	assert.EqualValues(t, "/holes?offset=10&limit=10", response.Links.Prev)
+	assert.EqualValues(t, "", response.Links.Next)
+
+	// a total of 0 is not omitted
+	app.Get("/empty", func(c *fiber.Ctx) error {
+		return c.JSON(NewPageResponse[int](c, nil, 0, PageRequest{Limit: 10}))
+	})
+	DefaultTester.Get(t, RequestConfig{
+		Route:        "/empty",
+		ExpectedBody: `{"items":[],"total":0,"links":{"self":"/empty"}}`,
+	})
+}
+
+func TestPageRequestValidate(t *testing.T) {
+	assert.Nil(t, ValidateStruct(&PageRequest{Limit: 10, Size: 10}))
+	assert.IsType(t, &ErrorDetail{}, ValidateStruct(&PageRequest{Limit: 0, Size: 10}))
+	assert.IsType(t, &ErrorDetail{}, ValidateStruct(&CursorRequest{Size: 0}))
+}
@@ -0,0 +1,501 @@
+package common
+
+import (
+	"bytes"
+	"mime"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	MIMEApplicationMergePatchJSON = "application/merge-patch+json"
+	MIMEApplicationJSONPatchJSON  = "application/json-patch+json"
+)
+
+// ApplyMergePatch applies the JSON Merge Patch (RFC 7386) in request body onto model, a pointer to struct.
+// The request should be application/merge-patch+json or application/json, otherwise 415 is returned.
+// Only fields whose json names are in allowed can be patched, patches to other fields like id are reported as
+// *ErrorDetail. The patched struct is validated by ValidateStruct before it is written back into model,
+// so model is unchanged on errors. It returns the go names of changed fields, which can be used in gorm updates:
+//
+//	changed, err := ApplyMergePatch(c, &hole, []string{"content", "hidden", "tags"})
+//	if err != nil {
+//		return err
+//	}
+//	err = DB.Model(&hole).Select(changed).Updates(&hole).Error
+func ApplyMergePatch(c *fiber.Ctx, model any, allowed []string) ([]string, error) {
+	if err := checkPatchContentType(c, MIMEApplicationMergePatchJSON, fiber.MIMEApplicationJSON); err != nil {
+		return nil, err
+	}
+	patch, err := decodeJSONValue(c.Body())
+	if err != nil {
+		return nil, BadRequest("merge patch: " + err.Error())
+	}
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return nil, BadRequest("merge patch: patch should be an object")
+	}
+
+	keys := make([]string, 0, len(patchObject))
+	for key := range patchObject {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var errorDetail ErrorDetail
+	for _, key := range keys {
+		if !patchAllowed(key, allowed) {
+			errorDetail = append(errorDetail, patchError(key, "/"+escapePointer(key)))
+		}
+	}
+	if len(errorDetail) > 0 {
+		return nil, &errorDetail
+	}
+
+	return applyPatch(model, func(doc any) (any, error) {
+		return mergePatch(doc, patch), nil
+	})
+}
+
+// JSONPatchOperation is an operation of JSON Patch
+type JSONPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyJSONPatch applies the JSON Patch (RFC 6902) in request body onto model, the same as ApplyMergePatch.
+// The request should be application/json-patch+json. Operations except test can only change fields in allowed.
+// A failed test operation is reported as 409 Conflict, and other invalid operations as 400 Bad Request.
+func ApplyJSONPatch(c *fiber.Ctx, model any, allowed []string) ([]string, error) {
+	if err := checkPatchContentType(c, MIMEApplicationJSONPatchJSON); err != nil {
+		return nil, err
+	}
+	var operations []JSONPatchOperation
+	if err := json.Unmarshal(c.Body(), &operations); err != nil {
+		return nil, BadRequest("json patch: " + err.Error())
+	}
+
+	var errorDetail ErrorDetail
+	for _, operation := range operations {
+		pointers := []string{operation.Path}
+		switch operation.Op {
+		case "test":
+			continue
+		case "move":
+			pointers = append(pointers, operation.From)
+		}
+		for _, pointer := range pointers {
+			// invalid pointers are reported when applied
+			path, err := parsePointer(pointer)
+			if err != nil {
+				continue
+			}
+			if len(path) == 0 {
+				return nil, BadRequest("json patch: the whole document can not be patched")
+			}
+			if !patchAllowed(path[0], allowed) {
+				errorDetail = append(errorDetail, patchError(path[0], pointer))
+			}
+		}
+	}
+	if len(errorDetail) > 0 {
+		return nil, &errorDetail
+	}
+
+	return applyPatch(model, func(doc any) (any, error) {
+		for i, operation := range operations {
+			var err error
+			if doc, err = applyJSONPatchOperation(doc, operation); err != nil {
+				if httpError, ok := err.(*HttpError); ok {
+					httpError.Message = "json patch: operation " + strconv.Itoa(i) + ": " + httpError.Message
+				}
+				return nil, err
+			}
+		}
+		return doc, nil
+	})
+}
+
+// checkPatchContentType checks that the media type of request is one of mediaTypes
+func checkPatchContentType(c *fiber.Ctx, mediaTypes ...string) error {
+	mediaType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	for _, allowed := range mediaTypes {
+		if mediaType == allowed {
+			return nil
+		}
+	}
+	return fiber.NewError(fiber.StatusUnsupportedMediaType, "content type should be "+strings.Join(mediaTypes, " or "))
+}
+
+func patchAllowed(name string, allowed []string) bool {
+	for _, field := range allowed {
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+// patchError reports that the field name, patched at JSON Pointer, is not allowed
+func patchError(name, pointer string) *ErrorDetailElement {
+	return &ErrorDetailElement{
+		Tag:         "patch",
+		Field:       name,
+		StructField: name,
+		Path:        name,
+		Pointer:     pointer,
+	}
+}
+
+// applyPatch patches model in json, validates it and writes it back, returning the changed fields
+func applyPatch(model any, patch func(doc any) (any, error)) ([]string, error) {
+	value := reflect.ValueOf(model)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return nil, InternalServerError("patch: model should be a pointer to struct")
+	}
+	value = value.Elem()
+
+	data, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeJSONValue(data)
+	if err != nil {
+		return nil, err
+	}
+	if doc, err = patch(doc); err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(doc); err != nil {
+		return nil, err
+	}
+
+	// fields in json are reset so that removed ones become zero, others like `json:"-"` are kept
+	patched := reflect.New(value.Type())
+	patched.Elem().Set(value)
+	fields := patchableFields(value.Type())
+	for _, field := range fields {
+		if fieldValue, err := patched.Elem().FieldByIndexErr(field.Index); err == nil {
+			fieldValue.Set(reflect.Zero(field.Type))
+		}
+	}
+	if err = json.Unmarshal(data, patched.Interface()); err != nil {
+		return nil, BadRequest("patch: " + err.Error())
+	}
+
+	if err = ValidateStruct(patched.Interface()); err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, field := range fields {
+		oldValue, oldErr := value.FieldByIndexErr(field.Index)
+		newValue, newErr := patched.Elem().FieldByIndexErr(field.Index)
+		if (oldErr == nil) != (newErr == nil) || oldErr == nil && patchFieldChanged(oldValue, newValue) {
+			changed = append(changed, field.Name)
+		}
+	}
+	value.Set(patched.Elem())
+	return changed, nil
+}
+
+// patchFieldChanged compares field values in json, as the patched struct is decoded from json.
+// Times are compared by Equal, as their locations and monotonic clock readings are lost in json.
+func patchFieldChanged(oldValue, newValue reflect.Value) bool {
+	switch old := oldValue.Interface().(type) {
+	case time.Time:
+		return !old.Equal(newValue.Interface().(time.Time))
+	case CustomTime:
+		return !old.Equal(newValue.Interface().(CustomTime).Time)
+	}
+	oldData, oldErr := json.Marshal(oldValue.Interface())
+	newData, newErr := json.Marshal(newValue.Interface())
+	return oldErr != nil || newErr != nil || !bytes.Equal(oldData, newData)
+}
+
+// patchableFields returns exported fields in json, including promoted ones
+func patchableFields(typ reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || field.Anonymous || field.Tag.Get("json") == "-" {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func decodeJSONValue(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// mergePatch applies patch onto target as RFC 7386
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any)
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatch(targetObject[key], value)
+		}
+	}
+	return targetObject
+}
+
+func applyJSONPatchOperation(doc any, operation JSONPatchOperation) (any, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	switch operation.Op {
+	case "add", "replace", "test":
+		if len(operation.Value) == 0 {
+			return nil, BadRequest("value is required")
+		}
+		if value, err = decodeJSONValue(operation.Value); err != nil {
+			return nil, BadRequest(err.Error())
+		}
+	}
+
+	switch operation.Op {
+	case "add":
+		return pointerAdd(doc, path, value, false)
+	case "replace":
+		return pointerAdd(doc, path, value, true)
+	case "remove":
+		doc, _, err = pointerRemove(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == "move" {
+			if strings.HasPrefix(operation.Path+"/", operation.From+"/") && operation.Path != operation.From {
+				return nil, BadRequest("can not move " + operation.From + " into itself")
+			}
+			if doc, value, err = pointerRemove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			if value, err = pointerGet(doc, from); err != nil {
+				return nil, err
+			}
+			// copy the value, as containers are shared
+			data, _ := json.Marshal(value)
+			value, _ = decodeJSONValue(data)
+		}
+		return pointerAdd(doc, path, value, false)
+	case "test":
+		current, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(current, value) {
+			return nil, Conflict("test failed at " + operation.Path)
+		}
+		return doc, nil
+	default:
+		return nil, BadRequest("unknown op " + operation.Op)
+	}
+}
+
+// parsePointer parses JSON Pointer (RFC 6901) into reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, BadRequest("invalid path " + pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses token as an index of array, "-" is the end of array if allowEnd
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > length || (index == length && !allowEnd) || (len(token) > 1 && token[0] == '0') {
+		return 0, BadRequest("invalid array index " + token)
+	}
+	return index, nil
+}
+
+func pointerGet(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch container := doc.(type) {
+		case map[string]any:
+			value, ok := container[token]
+			if !ok {
+				return nil, BadRequest("path " + token + " does not exist")
+			}
+			doc = value
+		case []any:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = container[index]
+		default:
+			return nil, BadRequest("path " + token + " does not exist")
+		}
+	}
+	return doc, nil
+}
+
+// pointerAdd adds value at path, or replaces the existing value if replace
+func pointerAdd(doc any, path []string, value any, replace bool) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token, rest := path[0], path[1:]
+
+	switch container := doc.(type) {
+	case map[string]any:
+		child, ok := container[token]
+		if !ok && (replace || len(rest) > 0) {
+			return nil, BadRequest("path " + token + " does not exist")
+		}
+		if len(rest) == 0 {
+			container[token] = value
+			return container, nil
+		}
+		child, err := pointerAdd(child, rest, value, replace)
+		if err != nil {
+			return nil, err
+		}
+		container[token] = child
+		return container, nil
+	case []any:
+		if len(rest) == 0 && !replace {
+			index, err := arrayIndex(token, len(container), true)
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		}
+		index, err := arrayIndex(token, len(container), false)
+		if err != nil {
+			return nil, err
+		}
+		if container[index], err = pointerAdd(container[index], rest, value, replace); err != nil {
+			return nil, err
+		}
+		return container, nil
+	default:
+		return nil, BadRequest("path " + token + " does not exist")
+	}
+}
+
+// pointerRemove removes the value at path and returns it
+func pointerRemove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, BadRequest("can not remove the whole document")
+	}
+	token, rest := path[0], path[1:]
+
+	switch container := doc.(type) {
+	case map[string]any:
+		child, ok := container[token]
+		if !ok {
+			return nil, nil, BadRequest("path " + token + " does not exist")
+		}
+		if len(rest) == 0 {
+			delete(container, token)
+			return container, child, nil
+		}
+		child, removed, err := pointerRemove(child, rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		container[token] = child
+		return container, removed, nil
+	case []any:
+		index, err := arrayIndex(token, len(container), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rest) == 0 {
+			removed := container[index]
+			return append(container[:index], container[index+1:]...), removed, nil
+		}
+		child, removed, err := pointerRemove(container[index], rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		container[index] = child
+		return container, removed, nil
+	default:
+		return nil, nil, BadRequest("path " + token + " does not exist")
+	}
+}
+
+// jsonEqual compares json values decoded with UseNumber, numbers are compared by value
+func jsonEqual(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+		return errA == nil && errB == nil && x == y
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,163 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPatchBase struct {
+	ID int `json:"id"`
+}
+
+type testPatchHole struct {
+	testPatchBase
+	Content   string            `json:"content" validate:"max=10"`
+	Hidden    bool              `json:"hidden"`
+	Tags      []string          `json:"tags"`
+	Extra     map[string]string `json:"extra"`
+	Secret    string            `json:"-"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func newTestPatchHole() testPatchHole {
+	return testPatchHole{
+		testPatchBase: testPatchBase{ID: 1},
+		Content:       "content",
+		Hidden:        true,
+		Tags:          []string{"a", "b"},
+		Extra:         map[string]string{"x": "1", "y": "2"},
+		Secret:        "secret",
+		CreatedAt:     time.Now(),
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Patch("/merge", func(c *fiber.Ctx) error {
+		hole := newTestPatchHole()
+		changed, err := ApplyMergePatch(c, &hole, []string{"content", "hidden", "tags", "extra", "created_at"})
+		if err != nil {
+			return err
+		}
+		return c.JSON(Map{"hole": hole, "changed": changed, "secret": hole.Secret})
+	})
+	app.Patch("/json", func(c *fiber.Ctx) error {
+		hole := newTestPatchHole()
+		changed, err := ApplyJSONPatch(c, &hole, []string{"content", "hidden", "tags", "extra", "created_at"})
+		if err != nil {
+			return err
+		}
+		return c.JSON(Map{"hole": hole, "changed": changed})
+	})
+	RegisterApp(app)
+
+	var response struct {
+		Hole    testPatchHole `json:"hole"`
+		Changed []string      `json:"changed"`
+		Secret  string        `json:"secret"`
+	}
+	DefaultTester.Patch(t, RequestConfig{
+		Route:         "/merge",
+		RequestBody:   `{"content":"new","hidden":null,"extra":{"x":null,"z":"3"}}`,
+		ContentType:   MIMEApplicationMergePatchJSON,
+		ResponseModel: &response,
+	})
+	assert.EqualValues(t, "new", response.Hole.Content)
+	assert.False(t, response.Hole.Hidden)
+	assert.EqualValues(t, map[string]string{"y": "2", "z": "3"}, response.Hole.Extra)
+	assert.EqualValues(t, []string{"Content", "Hidden", "Extra"}, response.Changed)
+	assert.EqualValues(t, "secret", response.Secret)
+
+	DefaultTester.Patch(t, RequestConfig{Route: "/merge", RequestBody: `{"content":"content too long"}`, ExpectedStatus: 400})
+	DefaultTester.Patch(t, RequestConfig{Route: "/merge", RequestBody: `[]`, ExpectedStatus: 400})
+
+	DefaultTester.Patch(t, RequestConfig{
+		Route:          "/merge",
+		RequestBody:    `{"content":"new"}`,
+		ContentType:    "text/plain",
+		ExpectedStatus: 415,
+	})
+
+	// fields not allowed can not be patched, even in other cases
+	var httpError HttpError
+	for _, body := range []string{`{"id":2}`, `{"ID":2}`, `{"content":"new","secret":"a"}`} {
+		DefaultTester.Patch(t, RequestConfig{Route: "/merge", RequestBody: body, ExpectedStatus: 400})
+	}
+	DefaultTester.Patch(t, RequestConfig{
+		Route:          "/merge",
+		RequestBody:    `{"user_id":2,"id":2}`,
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.EqualValues(t, "id不允许修改, user_id不允许修改", httpError.Message)
+	assert.EqualValues(t, "/id", (*httpError.Detail)[0].Pointer)
+
+	response.Hole, response.Changed = testPatchHole{}, nil
+	DefaultTester.Patch(t, RequestConfig{
+		Route: "/json",
+		RequestBody: `[
+			{"op":"test","path":"/id","value":1.0},
+			{"op":"add","path":"/tags/1","value":"c"},
+			{"op":"remove","path":"/tags/0"},
+			{"op":"add","path":"/tags/-","value":"d"},
+			{"op":"replace","path":"/content","value":"a/b"},
+			{"op":"copy","from":"/content","path":"/extra/a~1b"},
+			{"op":"move","from":"/extra/x","path":"/extra/w"}
+		]`,
+		ContentType:   MIMEApplicationJSONPatchJSON,
+		ResponseModel: &response,
+	})
+	assert.EqualValues(t, []string{"c", "b", "d"}, response.Hole.Tags)
+	assert.EqualValues(t, "a/b", response.Hole.Content)
+	assert.EqualValues(t, map[string]string{"w": "1", "y": "2", "a/b": "a/b"}, response.Hole.Extra)
+	assert.EqualValues(t, []string{"Content", "Tags", "Extra"}, response.Changed)
+
+	httpError = HttpError{}
+	DefaultTester.Patch(t, RequestConfig{
+		Route:          "/json",
+		RequestBody:    `[{"op":"test","path":"/content","value":"other"},{"op":"remove","path":"/content"}]`,
+		ContentType:    MIMEApplicationJSONPatchJSON,
+		ExpectedStatus: 409,
+		ResponseModel:  &httpError,
+	})
+	assert.EqualValues(t, "json patch: operation 0: test failed at /content", httpError.Message)
+
+	for _, body := range []string{
+		`[{"op":"replace","path":"/extra/unknown","value":"1"}]`,
+		`[{"op":"add","path":"/tags/5","value":"a"}]`,
+		`[{"op":"remove","path":"/tags/01"}]`,
+		`[{"op":"move","from":"/extra","path":"/extra/a"}]`,
+		`[{"op":"unknown","path":"/content"}]`,
+		`[{"op":"add","path":"/content"}]`,
+		`[{"op":"add","path":"content","value":1}]`,
+	} {
+		DefaultTester.Patch(t, RequestConfig{
+			Route:          "/json",
+			RequestBody:    body,
+			ContentType:    MIMEApplicationJSONPatchJSON,
+			ExpectedStatus: 400,
+		})
+	}
+	for _, body := range []string{
+		`[{"op":"replace","path":"/id","value":2}]`,
+		`[{"op":"remove","path":"/id"}]`,
+		`[{"op":"copy","from":"/content","path":"/id"}]`,
+		`[{"op":"move","from":"/id","path":"/content"}]`,
+		`[{"op":"replace","path":"","value":{"id":2}}]`,
+	} {
+		DefaultTester.Patch(t, RequestConfig{
+			Route:          "/json",
+			RequestBody:    body,
+			ContentType:    MIMEApplicationJSONPatchJSON,
+			ExpectedStatus: 400,
+		})
+	}
+	DefaultTester.Patch(t, RequestConfig{
+		Route:          "/json",
+		RequestBody:    `[{"op":"replace","path":"/content","value":"a"}]`,
+		ExpectedStatus: 415,
+	})
+}
@@ -0,0 +1,319 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	MIMEApplicationMsgPack  = "application/msgpack"
+	MIMEApplicationXMsgPack = "application/x-msgpack"
+	MIMEApplicationCBOR     = "application/cbor"
+)
+
+type marshalFunc func(v any) ([]byte, error)
+
+// renderOffers are the supported response content types, in order of preference
+var renderOffers = []string{
+	fiber.MIMEApplicationJSON,
+	fiber.MIMEApplicationXML,
+	fiber.MIMETextXML,
+	MIMEApplicationMsgPack,
+	MIMEApplicationXMsgPack,
+	MIMEApplicationCBOR,
+}
+
+var renderMarshalers = map[string]marshalFunc{
+	fiber.MIMEApplicationJSON: json.Marshal,
+	fiber.MIMEApplicationXML:  marshalXML,
+	fiber.MIMETextXML:         marshalXML,
+	MIMEApplicationMsgPack:    marshalMsgPack,
+	MIMEApplicationXMsgPack:   marshalMsgPack,
+	MIMEApplicationCBOR:       marshalCBOR,
+}
+
+// marshalMsgPack encodes v by its json representation, the same as marshalXML
+func marshalMsgPack(v any) ([]byte, error) {
+	value, err := jsonTree(v)
+	if err != nil {
+		return nil, err
+	}
+	return msgpack.Marshal(value)
+}
+
+// marshalCBOR encodes v by its json representation, the same as marshalXML
+func marshalCBOR(v any) ([]byte, error) {
+	value, err := jsonTree(v)
+	if err != nil {
+		return nil, err
+	}
+	return cbor.Marshal(value)
+}
+
+// jsonObject is a json object keeping the order of members, encoded as a map in MessagePack and CBOR
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	key   string
+	value any
+}
+
+func (o jsonObject) EncodeMsgpack(encoder *msgpack.Encoder) error {
+	if err := encoder.EncodeMapLen(len(o)); err != nil {
+		return err
+	}
+	for _, member := range o {
+		if err := encoder.EncodeString(member.key); err != nil {
+			return err
+		}
+		if err := encoder.Encode(member.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o jsonObject) MarshalCBOR() ([]byte, error) {
+	// the head of a map, major type 5 with the number of pairs as the argument
+	var buffer bytes.Buffer
+	switch length := len(o); {
+	case length < 24:
+		buffer.WriteByte(0xa0 | byte(length))
+	case length <= math.MaxUint8:
+		buffer.Write([]byte{0xb8, byte(length)})
+	case length <= math.MaxUint16:
+		buffer.WriteByte(0xb9)
+		buffer.Write(binary.BigEndian.AppendUint16(nil, uint16(length)))
+	default:
+		buffer.WriteByte(0xba)
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(length)))
+	}
+	for _, member := range o {
+		for _, item := range []any{member.key, member.value} {
+			data, err := cbor.Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+			buffer.Write(data)
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// jsonTree returns the json representation of v, with objects as jsonObject and numbers as int64, uint64 or float64
+func jsonTree(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decodeJSONTree(decoder)
+}
+
+// decodeJSONTree decodes the next json value in decoder, see jsonTree
+func decodeJSONTree(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		var value any
+		if token == '{' {
+			object := jsonObject{}
+			for decoder.More() {
+				member, err := decodeJSONMember(decoder)
+				if err != nil {
+					return nil, err
+				}
+				object = append(object, member)
+			}
+			value = object
+		} else {
+			array := make([]any, 0)
+			for decoder.More() {
+				item, err := decodeJSONTree(decoder)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, item)
+			}
+			value = array
+		}
+		// the closing delimiter
+		if _, err = decoder.Token(); err != nil {
+			return nil, err
+		}
+		return value, nil
+	case json.Number:
+		if i, err := token.Int64(); err == nil {
+			return i, nil
+		}
+		if u, err := strconv.ParseUint(token.String(), 10, 64); err == nil {
+			return u, nil
+		}
+		return token.Float64()
+	default:
+		return token, nil
+	}
+}
+
+func decodeJSONMember(decoder *json.Decoder) (jsonMember, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return jsonMember{}, err
+	}
+	key, _ := token.(string)
+	value, err := decodeJSONTree(decoder)
+	return jsonMember{key: key, value: value}, err
+}
+
+// marshalXML encodes v by its json representation, so that field names, omitted fields and custom marshalers
+// are the same as json, and maps are supported. Objects become child elements, items of arrays become <item>
+// elements, and null becomes an empty element. The root element is named by the xml tag of XMLName field,
+// or the type name.
+func marshalXML(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	encoder := xml.NewEncoder(&buffer)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = encodeXMLValue(encoder, decoder, xmlRootName(v)); err != nil {
+		return nil, err
+	}
+	if err = encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// xmlRootName returns the xml tag of XMLName field, or the name of type
+func xmlRootName(v any) string {
+	typ := reflect.TypeOf(v)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil {
+		return "response"
+	}
+	if typ.Kind() == reflect.Struct {
+		if field, ok := typ.FieldByName("XMLName"); ok && field.Type == reflect.TypeOf(xml.Name{}) {
+			if name, _, _ := strings.Cut(field.Tag.Get("xml"), ","); name != "" {
+				return name
+			}
+		}
+	}
+	if name, _, _ := strings.Cut(typ.Name(), "["); name != "" {
+		return name
+	}
+	return "response"
+}
+
+// xmlName replaces the characters not allowed in xml names with underscores
+func xmlName(name string) string {
+	var builder strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+			builder.WriteRune(r)
+		case r == '-' || r == '.' || unicode.IsDigit(r):
+			if i == 0 {
+				// names can not start with them, e.g. 1st to _1st
+				builder.WriteByte('_')
+			}
+			builder.WriteRune(r)
+		default:
+			builder.WriteByte('_')
+		}
+	}
+	if builder.Len() == 0 {
+		return "_"
+	}
+	return builder.String()
+}
+
+// encodeXMLValue encodes the next json value in decoder as an element named name
+func encodeXMLValue(encoder *xml.Encoder, decoder *json.Decoder, name string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	start := xml.StartElement{Name: xml.Name{Local: xmlName(name)}}
+	if err = encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		object := token == '{'
+		for decoder.More() {
+			childName := "item"
+			if object {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				childName, _ = key.(string)
+			}
+			if err = encodeXMLValue(encoder, decoder, childName); err != nil {
+				return err
+			}
+		}
+		// the closing delimiter
+		if _, err = decoder.Token(); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		if err = encoder.EncodeToken(xml.CharData(fmt.Sprint(token))); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// negotiate returns the content type accepted by the client, or empty string if none
+func negotiate(c *fiber.Ctx) string {
+	return c.Accepts(renderOffers...)
+}
+
+func render(c *fiber.Ctx, contentType string, status int, v any) error {
+	data, err := renderMarshalers[contentType](v)
+	if err != nil {
+		return err
+	}
+
+	c.Status(status)
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Send(data)
+}
+
+// Render sends v with status in the format negotiated by Accept header.
+// JSON, XML, MessagePack and CBOR are supported, JSON is used if Accept is absent.
+// Field names in XML, MessagePack and CBOR are the same as JSON.
+// If none of them is acceptable, a 406 HttpError is returned.
+func Render(c *fiber.Ctx, status int, v any) error {
+	contentType := negotiate(c)
+	if contentType == "" {
+		return NotAcceptable()
+	}
+	return render(c, contentType, status, v)
+}
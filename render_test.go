@@ -0,0 +1,106 @@
+package common
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestRender(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/floors/1", func(c *fiber.Ctx) error {
+		return Render(c, 200, testFloor{ID: 1, Content: "a"})
+	})
+	app.Get("/error", func(c *fiber.Ctx) error {
+		return NotFound()
+	})
+	app.Get("/validate", func(c *fiber.Ctx) error {
+		var body struct {
+			Content string `json:"content" validate:"required"`
+		}
+		return ValidateStruct(&body)
+	})
+	app.Get("/time", func(c *fiber.Ctx) error {
+		return Render(c, 200, Map{"time": CustomTime{time.Date(2022, 9, 9, 9, 52, 55, 0, TimeLocation)}, "zero": CustomTime{}})
+	})
+	app.Get("/map", func(c *fiber.Ctx) error {
+		return Render(c, 200, Map{"floors": []Map{{"id": 1}}, "total": 1, "1st": "x&y", "next": nil})
+	})
+
+	request := func(route, accept string) (int, string, []byte) {
+		req := httptest.NewRequest("GET", route, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		res, err := app.Test(req)
+		assert.Nil(t, err)
+		body, err := io.ReadAll(res.Body)
+		assert.Nil(t, err)
+		return res.StatusCode, res.Header.Get("Content-Type"), body
+	}
+
+	status, contentType, body := request("/floors/1", "")
+	assert.EqualValues(t, 200, status)
+	assert.EqualValues(t, fiber.MIMEApplicationJSON, contentType)
+	assert.EqualValues(t, `{"id":1,"content":"a"}`, string(body))
+
+	_, contentType, body = request("/floors/1", "application/xml")
+	assert.EqualValues(t, fiber.MIMEApplicationXML, contentType)
+	assert.EqualValues(t, `<testFloor><id>1</id><content>a</content></testFloor>`, string(body))
+
+	var floor testFloor
+	_, contentType, body = request("/floors/1", "application/msgpack")
+	assert.EqualValues(t, MIMEApplicationMsgPack, contentType)
+	var payload map[string]any
+	assert.Nil(t, msgpack.Unmarshal(body, &payload))
+	assert.EqualValues(t, "a", payload["content"])
+
+	_, contentType, body = request("/floors/1", "application/cbor, application/json;q=0.5")
+	assert.EqualValues(t, MIMEApplicationCBOR, contentType)
+	assert.Nil(t, cbor.Unmarshal(body, &floor))
+	assert.EqualValues(t, testFloor{ID: 1, Content: "a"}, floor)
+
+	// values are the same as json, e.g. CustomTime by TimeOutputLayout instead of binary
+	for _, accept := range []string{"application/msgpack", "application/cbor"} {
+		var payload map[string]any
+		_, _, body = request("/time", accept)
+		if accept == "application/cbor" {
+			assert.Nil(t, cbor.Unmarshal(body, &payload))
+		} else {
+			assert.Nil(t, msgpack.Unmarshal(body, &payload))
+		}
+		assert.EqualValues(t, map[string]any{"time": "2022-09-09T09:52:55+08:00", "zero": nil}, payload, accept)
+	}
+
+	status, _, _ = request("/floors/1", "text/html")
+	assert.EqualValues(t, fiber.StatusNotAcceptable, status)
+
+	// errors are rendered in the same format
+	var httpError HttpError
+	status, contentType, body = request("/error", "application/cbor")
+	assert.EqualValues(t, 404, status)
+	assert.EqualValues(t, MIMEApplicationCBOR, contentType)
+	assert.Nil(t, cbor.Unmarshal(body, &httpError))
+	assert.EqualValues(t, "Not Found", httpError.Message)
+
+	// xml uses json names, and supports maps and validation errors
+	status, contentType, body = request("/validate", "application/xml")
+	assert.EqualValues(t, 400, status)
+	assert.EqualValues(t, fiber.MIMEApplicationXML, contentType)
+	assert.Contains(t, string(body), `<detail><item><tag>required</tag><field>content</field>`)
+	assert.NotContains(t, string(body), `Kind`)
+
+	status, _, body = request("/map", "application/xml")
+	assert.EqualValues(t, 200, status)
+	assert.EqualValues(t, `<response><_1st>x&amp;y</_1st><floors><item><id>1</id></item></floors><next></next><total>1</total></response>`, string(body))
+
+	status, contentType, _ = request("/error", "text/html")
+	assert.EqualValues(t, 404, status)
+	assert.EqualValues(t, fiber.MIMEApplicationJSON, contentType)
+}
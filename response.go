@@ -0,0 +1,98 @@
+package common
+
+import (
+	"encoding/xml"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ResponseMode decides how response helpers render data
+type ResponseMode int
+
+const (
+	// ResponseModeBare renders data as is, the default mode
+	ResponseModeBare ResponseMode = iota
+
+	// ResponseModeEnvelope wraps data in Response or ListResponse,
+	// which has code and message as HttpError does
+	ResponseModeEnvelope
+)
+
+// MiddlewareResponseMode sets the response mode of requests, NewApp registers it with AppOptions.ResponseMode
+//
+//	app.Use(MiddlewareResponseMode(ResponseModeEnvelope))
+func MiddlewareResponseMode(mode ResponseMode) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals("response_mode", mode)
+		return c.Next()
+	}
+}
+
+func getResponseMode(c *fiber.Ctx) ResponseMode {
+	if mode, ok := c.Locals("response_mode").(ResponseMode); ok {
+		return mode
+	}
+	return ResponseModeBare
+}
+
+// Response is the envelope of a single object
+type Response[T any] struct {
+	XMLName xml.Name `json:"-" xml:"response"`
+	Code    int      `json:"code"`
+	Message string   `json:"message,omitempty"`
+	Data    T        `json:"data"`
+}
+
+// ListResponse is the envelope of a list, with pagination info
+type ListResponse[T any] struct {
+	XMLName xml.Name  `json:"-" xml:"response"`
+	Code    int       `json:"code"`
+	Message string    `json:"message,omitempty"`
+	Data    []T       `json:"data"`
+	Meta    *PageMeta `json:"meta,omitempty"`
+}
+
+func sendData[T any](c *fiber.Ctx, status int, data T) error {
+	if getResponseMode(c) == ResponseModeEnvelope {
+		return Render(c, status, &Response[T]{Code: status, Data: data})
+	}
+	return Render(c, status, data)
+}
+
+// OK sends data with status 200
+func OK[T any](c *fiber.Ctx, data T) error {
+	return sendData(c, fiber.StatusOK, data)
+}
+
+// Created sends data with status 201 and Location header if location is not empty
+func Created[T any](c *fiber.Ctx, location string, data T) error {
+	if location != "" {
+		c.Location(location)
+	}
+	return sendData(c, fiber.StatusCreated, data)
+}
+
+// Accepted sends a message with status 202 and Location header of the task if location is not empty
+func Accepted(c *fiber.Ctx, location string) error {
+	if location != "" {
+		c.Location(location)
+	}
+	if getResponseMode(c) == ResponseModeEnvelope {
+		return Render(c, fiber.StatusAccepted, &Response[any]{Code: fiber.StatusAccepted, Message: "Accepted"})
+	}
+	return Render(c, fiber.StatusAccepted, Message("Accepted"))
+}
+
+// NoContent sends status 204 without body
+func NoContent(c *fiber.Ctx) error {
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// List sends a page of items with status 200.
+// In envelope mode, items are in data and pagination info is in meta.
+func List[T any](c *fiber.Ctx, page *PageResponse[T]) error {
+	if getResponseMode(c) == ResponseModeEnvelope {
+		return Render(c, fiber.StatusOK, &ListResponse[T]{Code: fiber.StatusOK, Data: page.Items, Meta: &page.PageMeta})
+	}
+	return Render(c, fiber.StatusOK, page)
+}
@@ -0,0 +1,70 @@
+package common
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponse(t *testing.T) {
+	for _, mode := range []ResponseMode{ResponseModeBare, ResponseModeEnvelope} {
+		app := NewApp(AppOptions{ResponseMode: mode})
+		app.Get("/floors/1", func(c *fiber.Ctx) error {
+			return OK(c, testFloor{ID: 1})
+		})
+		app.Post("/floors", func(c *fiber.Ctx) error {
+			return Created(c, "/floors/2", testFloor{ID: 2})
+		})
+		app.Post("/tasks", func(c *fiber.Ctx) error {
+			return Accepted(c, "/tasks/1")
+		})
+		app.Delete("/floors/1", func(c *fiber.Ctx) error {
+			return NoContent(c)
+		})
+		app.Get("/floors", func(c *fiber.Ctx) error {
+			return List(c, &PageResponse[testFloor]{Items: []testFloor{{ID: 1}}, PageMeta: PageMeta{Total: 1}})
+		})
+		RegisterApp(app)
+
+		if mode == ResponseModeBare {
+			DefaultTester.Get(t, RequestConfig{Route: "/floors/1", ExpectedBody: `{"id":1,"content":""}`})
+			DefaultTester.Post(t, RequestConfig{Route: "/floors", ExpectedBody: `{"id":2,"content":""}`})
+			DefaultTester.Post(t, RequestConfig{Route: "/tasks", ExpectedStatus: 202, ExpectedBody: `{"message":"Accepted"}`})
+			DefaultTester.Get(t, RequestConfig{Route: "/floors", ExpectedBody: `{"items":[{"id":1,"content":""}],"total":1,"links":{}}`})
+		} else {
+			DefaultTester.Get(t, RequestConfig{Route: "/floors/1", ExpectedBody: `{"code":200,"data":{"id":1,"content":""}}`})
+			DefaultTester.Post(t, RequestConfig{Route: "/floors", ExpectedBody: `{"code":201,"data":{"id":2,"content":""}}`})
+			DefaultTester.Post(t, RequestConfig{Route: "/tasks", ExpectedStatus: 202, ExpectedBody: `{"code":202,"message":"Accepted","data":null}`})
+			DefaultTester.Get(t, RequestConfig{Route: "/floors", ExpectedBody: `{"code":200,"data":[{"id":1,"content":""}],"meta":{"total":1,"links":{}}}`})
+		}
+		DefaultTester.Delete(t, RequestConfig{Route: "/floors/1", ExpectedStatus: 204})
+
+		res, err := app.Test(httptest.NewRequest("POST", "/floors", nil))
+		assert.Nil(t, err)
+		assert.EqualValues(t, "/floors/2", res.Header.Get("Location"))
+	}
+}
+
+func TestMiddlewareResponseMode(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(MiddlewareResponseMode(ResponseModeEnvelope))
+	app.Get("/floors/1", func(c *fiber.Ctx) error {
+		return OK(c, testFloor{ID: 1})
+	})
+	RegisterApp(app)
+
+	DefaultTester.Get(t, RequestConfig{Route: "/floors/1", ExpectedBody: `{"code":200,"data":{"id":1,"content":""}}`})
+}
+
+func TestMessageWithData(t *testing.T) {
+	app := fiber.New()
+	app.Get("/message", func(c *fiber.Ctx) error {
+		return c.JSON(Message("hello").WithData(testFloor{ID: 1}))
+	})
+	RegisterApp(app)
+
+	DefaultTester.Get(t, RequestConfig{Route: "/message", ExpectedBody: `{"message":"hello","data":{"id":1,"content":""}}`})
+	assert.Nil(t, Message("hello").Data)
+}
@@ -0,0 +1,147 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// StrictBody makes ValidateBody reject unknown fields, duplicate keys and trailing data in json body.
+// It is false by default; use ValidateBodyStrict to enable strict mode per call.
+var StrictBody = false
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// checkStrictJSON reports unknown fields, duplicate keys and trailing data in json body against typ
+func checkStrictJSON(body []byte, typ reflect.Type) (ErrorDetail, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var errorDetail ErrorDetail
+	if err := walkStrictJSON(decoder, typ, "", &errorDetail); err != nil {
+		return nil, BadRequest(err.Error())
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		errorDetail = append(errorDetail, &ErrorDetailElement{Tag: "trailing_data"})
+	}
+	return errorDetail, nil
+}
+
+// walkStrictJSON reads one json value, typ is nil if the value is not checked against a type
+func walkStrictJSON(decoder *json.Decoder, typ reflect.Type, path string, errorDetail *ErrorDetail) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if valueType, ok := optionalValueType(typ); ok {
+		typ = valueType
+	}
+	if typ != nil && (reflect.PointerTo(typ).Implements(jsonUnmarshalerType) || reflect.PointerTo(typ).Implements(textUnmarshalerType)) {
+		// custom unmarshaler decides the fields itself
+		typ = nil
+	}
+
+	switch delim {
+	case '{':
+		var fields map[string]reflect.Type
+		var elemType reflect.Type
+		if typ != nil {
+			switch typ.Kind() {
+			case reflect.Struct:
+				fields = jsonFields(typ)
+			case reflect.Map:
+				elemType = typ.Elem()
+			}
+		}
+
+		seen := make(map[string]bool)
+		for decoder.More() {
+			token, err = decoder.Token()
+			if err != nil {
+				return err
+			}
+			key := token.(string)
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+
+			// struct fields are matched case-insensitively by the decoder, so {"a":1,"A":2} are duplicate keys
+			seenKey := key
+			fieldType := elemType
+			if fields != nil {
+				var name string
+				name, fieldType = lookupJSONField(fields, key)
+				if fieldType == nil {
+					*errorDetail = append(*errorDetail, &ErrorDetailElement{
+						Tag:         "unknown_field",
+						Field:       fieldPath,
+						StructField: fieldPath,
+						Path:        fieldPath,
+						Pointer:     jsonPointer(fieldPath),
+					})
+					name = strings.ToLower(key)
+				}
+				seenKey = name
+			}
+
+			if seen[seenKey] {
+				*errorDetail = append(*errorDetail, &ErrorDetailElement{
+					Tag:         "duplicate_key",
+					Field:       fieldPath,
+					StructField: fieldPath,
+					Path:        fieldPath,
+					Pointer:     jsonPointer(fieldPath),
+				})
+			}
+			seen[seenKey] = true
+
+			if err = walkStrictJSON(decoder, fieldType, fieldPath, errorDetail); err != nil {
+				return err
+			}
+		}
+	case '[':
+		var elemType reflect.Type
+		if typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+			elemType = typ.Elem()
+		}
+		for i := 0; decoder.More(); i++ {
+			if err = walkStrictJSON(decoder, elemType, path+"["+strconv.Itoa(i)+"]", errorDetail); err != nil {
+				return err
+			}
+		}
+	}
+
+	// closing delim
+	_, err = decoder.Token()
+	return err
+}
+
+// lookupJSONField matches key as json does, exact match preferred over case-insensitive match.
+// It returns the name and type of the matched field, or nil type if not found.
+func lookupJSONField(fields map[string]reflect.Type, key string) (string, reflect.Type) {
+	if fieldType, ok := fields[key]; ok {
+		return key, fieldType
+	}
+	for name, fieldType := range fields {
+		if strings.EqualFold(name, key) {
+			return name, fieldType
+		}
+	}
+	return "", nil
+}
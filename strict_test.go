@@ -0,0 +1,71 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateBodyStrict(t *testing.T) {
+	type Option struct {
+		Color string `json:"color"`
+	}
+	type Body struct {
+		Content string            `json:"content"`
+		Tags    []Option          `json:"tags"`
+		Extra   map[string]Option `json:"extra"`
+		Any     any               `json:"any"`
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/strict", func(c *fiber.Ctx) error {
+		var body Body
+		if err := ValidateBodyStrict(c, &body); err != nil {
+			return err
+		}
+		return c.Status(201).JSON(body)
+	})
+	app.Post("/lenient", func(c *fiber.Ctx) error {
+		var body Body
+		if err := ValidateBody(c, &body); err != nil {
+			return err
+		}
+		return c.Status(201).JSON(body)
+	})
+	RegisterApp(app)
+
+	valid := `{"content":"a","Tags":[{"color":"red"}],"extra":{"k":{"color":"blue"}},"any":{"x":1}}`
+	DefaultTester.Post(t, RequestConfig{Route: "/strict", RequestBody: valid})
+
+	var httpError HttpError
+	DefaultTester.Post(t, RequestConfig{
+		Route:          "/strict",
+		RequestBody:    `{"conent":"a","content":"b","content":"c","tags":[{"color":"red"},{"colr":"red"}],"extra":{"k":{"x":1}}}`,
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.EqualValues(t, "未知字段conent, 重复字段content, 未知字段tags[1].colr, 未知字段extra.k.x", httpError.Message)
+
+	// keys are matched case-insensitively as the decoder does, except for maps
+	DefaultTester.Post(t, RequestConfig{
+		Route:          "/strict",
+		RequestBody:    `{"content":"a","Content":"b","extra":{"k":{},"K":{}}}`,
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.EqualValues(t, "重复字段Content", httpError.Message)
+
+	DefaultTester.Post(t, RequestConfig{
+		Route:          "/strict",
+		RequestBody:    `{"content":"a"} {}`,
+		ExpectedStatus: 400,
+		ResponseModel:  &httpError,
+	})
+	assert.EqualValues(t, "请求体末尾有多余数据", httpError.Message)
+
+	DefaultTester.Post(t, RequestConfig{Route: "/strict", RequestBody: `{"content":`, ExpectedStatus: 400})
+
+	// lenient by default
+	DefaultTester.Post(t, RequestConfig{Route: "/lenient", RequestBody: `{"conent":"a"}`})
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// RegisterStructValidation registers a struct level validation for T, run after its fields are validated.
+// Report errors on fields with ReportError, they are aggregated into *ErrorDetail by ValidateStruct.
+//
+//	RegisterStructValidation(func(sl validator.StructLevel, r *TimeRange) {
+//		if !r.Start.Before(r.End) {
+//			ReportError(sl, "End", "gtfield", "Start")
+//		}
+//	})
+func RegisterStructValidation[T any](fn func(sl validator.StructLevel, value *T)) {
+	var zero T
+	Validate.RegisterStructValidation(func(sl validator.StructLevel) {
+		current := sl.Current()
+		if current.CanAddr() {
+			fn(sl, current.Addr().Interface().(*T))
+			return
+		}
+		value := current.Interface().(T)
+		fn(sl, &value)
+	}, zero)
+}
+
+// ReportError reports a validation error on the field of the current struct by its go field name,
+// the field name in errors is its json name as field level errors.
+// For cross-field tags like gtfield, param is the go name of the other field.
+func ReportError(sl validator.StructLevel, structField, tag, param string) {
+	current := sl.Current()
+	field, ok := current.Type().FieldByName(structField)
+	if !ok {
+		panic("ReportError: no field " + structField + " in " + current.Type().String())
+	}
+	name := jsonTagName(field)
+	if name == "" {
+		name = field.Name
+	}
+	sl.ReportError(current.FieldByIndex(field.Index).Interface(), name, field.Name, tag, param)
+}
+
+// crossFieldTags are the tags whose param is another field, cs tags are relative to the top level struct
+var crossFieldTags = map[string]bool{
+	"eqfield": true, "nefield": true, "gtfield": true, "gtefield": true, "ltfield": true, "ltefield": true,
+	"fieldcontains": true, "fieldexcludes": true,
+	"eqcsfield": true, "necsfield": true, "gtcsfield": true, "gtecsfield": true, "ltcsfield": true, "ltecsfield": true,
+}
+
+// crossFieldParam resolves the other field of a cross-field error, returns its json name and label.
+// ok is false if the tag is not a cross-field tag or the field is not found.
+func crossFieldParam(modelType reflect.Type, structNamespace, tag, param string) (name, label string, ok bool) {
+	if !crossFieldTags[tag] {
+		return "", "", false
+	}
+
+	var namespace string
+	if strings.Contains(tag, "csfield") {
+		// relative to the top level struct, whose namespace is the type name or empty for anonymous structs
+		namespace = strings.TrimSuffix(strings.TrimSuffix(structNamespace, fieldNamespace(modelType, structNamespace)), ".")
+	} else if i := strings.LastIndexByte(structNamespace, '.'); i >= 0 {
+		// sibling field
+		namespace = structNamespace[:i]
+	}
+	if namespace != "" {
+		param = namespace + "." + param
+	}
+
+	field, ok := structFieldByNamespace(modelType, param)
+	if !ok {
+		return "", "", false
+	}
+	name = jsonTagName(field)
+	if name == "" {
+		name = field.Name
+	}
+	return name, fieldLabel(field), true
+}
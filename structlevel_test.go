@@ -0,0 +1,55 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+type testContactRequest struct {
+	Email string `json:"email" label:"邮箱"`
+	Phone string `json:"phone" label:"手机号"`
+}
+
+type testRangeRequest struct {
+	Start int `json:"start" label:"开始"`
+	End   int `json:"end" label:"结束" validate:"gtfield=Start"`
+	Inner struct {
+		Max int `json:"max" validate:"ltecsfield=End"`
+	} `json:"inner"`
+}
+
+func TestStructValidation(t *testing.T) {
+	RegisterStructValidation(func(sl validator.StructLevel, r *testContactRequest) {
+		if r.Email == "" && r.Phone == "" {
+			ReportError(sl, "Email", "required_without", "Phone")
+			ReportError(sl, "Phone", "required_without", "Email")
+		}
+	})
+
+	assert.Nil(t, ValidateStruct(&testContactRequest{Email: "a@b.c"}))
+	assert.Nil(t, ValidateStruct(testContactRequest{Phone: "13812345678"}))
+
+	err := ValidateStruct(&testContactRequest{})
+	assert.NotNil(t, err)
+	errorDetail := *err.(*ErrorDetail)
+	assert.Len(t, errorDetail, 2)
+	assert.EqualValues(t, "email", errorDetail[0].Field)
+	assert.EqualValues(t, "Email", errorDetail[0].StructField)
+	assert.EqualValues(t, "/email", errorDetail[0].Pointer)
+	assert.EqualValues(t, "邮箱不能为空, 手机号不能为空", err.Error())
+}
+
+func TestCrossFieldMessages(t *testing.T) {
+	request := testRangeRequest{Start: 2, End: 1}
+	request.Inner.Max = 3
+	err := ValidateStruct(&request)
+	assert.NotNil(t, err)
+	errorDetail := *err.(*ErrorDetail)
+	assert.EqualValues(t, "start", errorDetail[0].Param)
+	assert.EqualValues(t, "end", errorDetail[1].Param)
+	assert.EqualValues(t, "inner.max", errorDetail[1].Path)
+	assert.EqualValues(t, "结束必须大于开始, max必须小于或等于结束", err.Error())
+	assert.EqualValues(t, "结束 must be greater than 开始", errorDetail.Translate(LanguageEN)[0].Message)
+}
@@ -1,6 +1,9 @@
 package common
 
 import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -9,7 +12,90 @@ type CustomTime struct {
 	time.Time
 }
 
-var locCST = time.FixedZone("CST", 8*3600)
+// Special layouts of CustomTime for unix timestamps, in json they are numbers
+const (
+	TimeLayoutUnix      = "unix"
+	TimeLayoutUnixMilli = "unixmilli"
+)
+
+var (
+	// TimeOutputLayout is the layout of CustomTime in json and text, TimeLayoutUnix and TimeLayoutUnixMilli are supported.
+	// Set TimeInputLayouts accordingly to parse the output back, e.g. with TimeLayoutUnixMilli.
+	TimeOutputLayout = time.RFC3339Nano
+
+	// TimeInputLayouts are tried in order to parse CustomTime from json, text and sql strings.
+	// Layouts without time zone are parsed in TimeLocation.
+	// Integers are parsed by the first of TimeLayoutUnix and TimeLayoutUnixMilli, the unit is never guessed,
+	// so replace TimeLayoutUnix with TimeLayoutUnixMilli to accept timestamps in milliseconds.
+	TimeInputLayouts = []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05Z07:00",
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+		TimeLayoutUnix,
+	}
+
+	// TimeLocation is the default time zone of CustomTime for layouts without time zone
+	TimeLocation = time.FixedZone("CST", 8*3600)
+)
+
+// ParseTime parses s by TimeInputLayouts
+func ParseTime(s string) (time.Time, error) {
+	for _, layout := range TimeInputLayouts {
+		switch layout {
+		case TimeLayoutUnix, TimeLayoutUnixMilli:
+			timestamp, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				continue
+			}
+			return unixTime(timestamp), nil
+		default:
+			// Fractional seconds are handled implicitly by Parse.
+			if t, err := time.ParseInLocation(layout, s, TimeLocation); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("parsing time %q: unknown layout", s)
+}
+
+// unixTime converts timestamp in the unit of the first unix layout in TimeInputLayouts, seconds if there is none
+func unixTime(timestamp int64) time.Time {
+	for _, layout := range TimeInputLayouts {
+		switch layout {
+		case TimeLayoutUnixMilli:
+			return time.UnixMilli(timestamp).In(TimeLocation)
+		case TimeLayoutUnix:
+			return time.Unix(timestamp, 0).In(TimeLocation)
+		}
+	}
+	return time.Unix(timestamp, 0).In(TimeLocation)
+}
+
+// formatTime formats t by TimeOutputLayout
+func formatTime(t time.Time) string {
+	switch TimeOutputLayout {
+	case TimeLayoutUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case TimeLayoutUnixMilli:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	default:
+		return t.Format(TimeOutputLayout)
+	}
+}
+
+// MarshalJSON returns null for zero time, like UnmarshalJSON ignores null
+func (ct CustomTime) MarshalJSON() ([]byte, error) {
+	if ct.IsZero() {
+		return []byte("null"), nil
+	}
+	s := formatTime(ct.Time)
+	if TimeOutputLayout == TimeLayoutUnix || TimeOutputLayout == TimeLayoutUnixMilli {
+		return []byte(s), nil
+	}
+	return []byte(strconv.Quote(s)), nil
+}
 
 func (ct *CustomTime) UnmarshalJSON(data []byte) error {
 	s := strings.Trim(string(data), `"`)
@@ -17,26 +103,61 @@ func (ct *CustomTime) UnmarshalJSON(data []byte) error {
 	if s == "null" {
 		return nil
 	}
-	// Fractional seconds are handled implicitly by Parse.
 	var err error
-	ct.Time, err = time.Parse(time.RFC3339, s)
-	if err != nil {
-		ct.Time, err = time.ParseInLocation(`2006-01-02T15:04:05`, s, locCST)
-	}
+	ct.Time, err = ParseTime(s)
 	return err
 }
 
+// MarshalText returns empty string for zero time, like UnmarshalText ignores empty string
+func (ct CustomTime) MarshalText() ([]byte, error) {
+	if ct.IsZero() {
+		return []byte{}, nil
+	}
+	return []byte(formatTime(ct.Time)), nil
+}
+
 func (ct *CustomTime) UnmarshalText(data []byte) error {
 	s := strings.Trim(string(data), `"`)
-	// Ignore null, like in the main JSON package.
+	// Ignore empty string, like null in JSON.
 	if s == "" {
 		return nil
 	}
-	// Fractional seconds are handled implicitly by Parse.
 	var err error
-	ct.Time, err = time.Parse(time.RFC3339, s)
-	if err != nil {
-		ct.Time, err = time.ParseInLocation(`2006-01-02T15:04:05`, s, locCST)
-	}
+	ct.Time, err = ParseTime(s)
 	return err
 }
+
+// Scan implements sql.Scanner, it accepts time, strings by TimeInputLayouts
+// and unix timestamps in the unit of TimeInputLayouts, seconds by default
+func (ct *CustomTime) Scan(value any) error {
+	switch value := value.(type) {
+	case nil:
+		ct.Time = time.Time{}
+	case time.Time:
+		ct.Time = value
+	case []byte:
+		ct.Time = time.Time{}
+		return ct.UnmarshalText(value)
+	case string:
+		ct.Time = time.Time{}
+		return ct.UnmarshalText([]byte(value))
+	case int64:
+		ct.Time = unixTime(value)
+	default:
+		return fmt.Errorf("unsupported type %T of CustomTime", value)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer, zero time is stored as NULL
+func (ct CustomTime) Value() (driver.Value, error) {
+	if ct.IsZero() {
+		return nil, nil
+	}
+	return ct.Time, nil
+}
+
+// GormDataType makes gorm create time columns, e.g. datetime in MySQL
+func (CustomTime) GormDataType() string {
+	return "time"
+}
@@ -2,9 +2,14 @@ package common
 
 import (
 	"fmt"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/schema"
 )
 
 type TimeModel struct {
@@ -36,3 +41,117 @@ func TestTimeString(t *testing.T) {
 		fmt.Println(string(jsonBytes))
 	}
 }
+
+func TestTimeLayouts(t *testing.T) {
+	expected := time.Date(2022, 9, 9, 9, 52, 55, 0, TimeLocation)
+	for _, s := range []string{
+		`"2022-09-09T09:52:55+08:00"`,
+		`"2022-09-09T09:52:55"`,
+		`"2022-09-09 09:52:55"`,
+		`"2022-09-09 01:52:55Z"`,
+		`1662688375`,
+		`"1662688375"`,
+	} {
+		var ct CustomTime
+		err := json.Unmarshal([]byte(s), &ct)
+		assert.Nil(t, err, s)
+		assert.True(t, expected.Equal(ct.Time), s)
+	}
+
+	var ct CustomTime
+	assert.Nil(t, ct.UnmarshalText([]byte("2022-09-09")))
+	assert.EqualValues(t, time.Date(2022, 9, 9, 0, 0, 0, 0, TimeLocation), ct.Time)
+	assert.NotNil(t, ct.UnmarshalText([]byte("09/09/2022")))
+
+	// the unit of timestamps is set by layouts instead of guessed from the value
+	parsed, err := ParseTime("1662688375000")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1662688375000, parsed.Unix())
+	defer func(layouts []string) { TimeInputLayouts = layouts }(TimeInputLayouts)
+	TimeInputLayouts = []string{time.RFC3339, TimeLayoutUnixMilli, TimeLayoutUnix}
+	for _, s := range []string{"1662688375000", "1662688375"} {
+		parsed, err = ParseTime(s)
+		assert.Nil(t, err)
+		assert.EqualValues(t, s, strconv.FormatInt(parsed.UnixMilli(), 10))
+	}
+	// numbers are rejected without unix layouts
+	TimeInputLayouts = []string{time.RFC3339}
+	_, err = ParseTime("20220909")
+	assert.NotNil(t, err)
+}
+
+func TestTimeMarshal(t *testing.T) {
+	model := TimeModel{Time: CustomTime{time.Date(2022, 9, 9, 9, 52, 55, 0, TimeLocation)}}
+
+	data, err := json.Marshal(model)
+	assert.Nil(t, err)
+	assert.EqualValues(t, `{"time":"2022-09-09T09:52:55+08:00"}`, string(data))
+
+	var decoded TimeModel
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.True(t, model.Time.Equal(decoded.Time.Time))
+
+	defer func(layout string, layouts []string) {
+		TimeOutputLayout, TimeInputLayouts = layout, layouts
+	}(TimeOutputLayout, TimeInputLayouts)
+	TimeOutputLayout = TimeLayoutUnixMilli
+	TimeInputLayouts = []string{time.RFC3339, TimeLayoutUnixMilli}
+	data, err = json.Marshal(model)
+	assert.Nil(t, err)
+	assert.EqualValues(t, `{"time":1662688375000}`, string(data))
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.True(t, model.Time.Equal(decoded.Time.Time))
+
+	TimeOutputLayout = "2006-01-02 15:04"
+	text, err := model.Time.MarshalText()
+	assert.Nil(t, err)
+	assert.EqualValues(t, "2022-09-09 09:52", string(text))
+
+	// zero time is null, and unmarshalled back as zero
+	data, err = json.Marshal(TimeModel{})
+	assert.Nil(t, err)
+	assert.EqualValues(t, `{"time":null}`, string(data))
+	decoded = TimeModel{}
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.True(t, decoded.Time.IsZero())
+	text, err = CustomTime{}.MarshalText()
+	assert.Nil(t, err)
+	assert.EqualValues(t, "", string(text))
+}
+
+func TestTimeSQL(t *testing.T) {
+	expected := time.Date(2022, 9, 9, 9, 52, 55, 0, TimeLocation)
+	for _, value := range []any{expected, "2022-09-09 09:52:55", []byte("2022-09-09T09:52:55+08:00"), int64(1662688375)} {
+		var ct CustomTime
+		assert.Nil(t, ct.Scan(value))
+		assert.True(t, expected.Equal(ct.Time), value)
+	}
+
+	ct := CustomTime{expected}
+	assert.Nil(t, ct.Scan(nil))
+	assert.True(t, ct.IsZero())
+	assert.NotNil(t, ct.Scan(1.5))
+
+	// timestamps are in the unit of TimeInputLayouts
+	layouts := TimeInputLayouts
+	TimeInputLayouts = []string{time.RFC3339, TimeLayoutUnixMilli}
+	assert.Nil(t, ct.Scan(int64(1662688375000)))
+	assert.True(t, expected.Equal(ct.Time))
+	TimeInputLayouts = layouts
+
+	value, err := CustomTime{expected}.Value()
+	assert.Nil(t, err)
+	assert.EqualValues(t, expected, value)
+	value, err = CustomTime{}.Value()
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+	assert.EqualValues(t, "time", CustomTime{}.GormDataType())
+
+	type hole struct {
+		ID        int
+		CreatedAt CustomTime
+	}
+	holeSchema, err := schema.Parse(&hole{}, &sync.Map{}, schema.NamingStrategy{})
+	assert.Nil(t, err)
+	assert.EqualValues(t, schema.Time, holeSchema.LookUpField("CreatedAt").DataType)
+}
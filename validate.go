@@ -16,36 +16,18 @@ type ErrorDetailElement struct {
 	Value       any          `json:"value"`
 	Param       string       `json:"param"`
 	StructField string       `json:"struct_field"`
+	Label       string       `json:"label,omitempty"`   // human-readable name used in messages, by LabelFunc
+	Path        string       `json:"path,omitempty"`    // JSON path in body, e.g. tags[2].name
+	Pointer     string       `json:"pointer,omitempty"` // JSON Pointer in body, e.g. /tags/2/name
 	Message     string       `json:"message"`
+
+	paramLabel      string // label of the other field of cross-field tags, used in messages instead of Param
+	structNamespace string // struct namespace without the type name, e.g. Tags[0].Name
 }
 
+// Error returns the message in DefaultLanguage, use Translate for other languages
 func (e *ErrorDetailElement) Error() string {
-	if e.Message != "" {
-		return e.Message
-	}
-
-	switch e.Tag {
-	case "min":
-		if e.Kind == reflect.String {
-			e.Message = e.Field + "至少" + e.Param + "字符"
-		} else {
-			e.Message = e.Field + "至少为" + e.Param
-		}
-	case "max":
-		if e.Kind == reflect.String {
-			e.Message = e.Field + "限长" + e.Param + "字符"
-		} else {
-			e.Message = e.Field + "至多为" + e.Param
-		}
-	case "required":
-		e.Message = e.Field + "不能为空"
-	case "email":
-		e.Message = "邮箱格式不正确"
-	default:
-		e.Message = e.StructField + "格式不正确"
-	}
-
-	return e.Message
+	return e.Translate(DefaultLanguage)
 }
 
 type ErrorDetail []*ErrorDetailElement
@@ -71,29 +53,48 @@ func (e ErrorDetail) Error() string {
 var Validate = validator.New()
 
 func init() {
-	Validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
-		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+	Validate.RegisterTagNameFunc(jsonTagName)
+}
 
-		if name == "-" {
-			return ""
-		}
+// jsonTagName returns the name in json tag of the field, or empty string if ignored
+func jsonTagName(fld reflect.StructField) string {
+	name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
 
-		return name
-	})
+	if name == "-" {
+		return ""
+	}
+
+	return name
 }
 
 func ValidateStruct(model any) error {
 	errors := Validate.Struct(model)
 	if errors != nil {
 		var errorDetail ErrorDetail
+		modelType := reflect.TypeOf(model)
 		for _, err := range errors.(validator.ValidationErrors) {
+			var label string
+			if field, ok := structFieldByNamespace(modelType, err.StructNamespace()); ok {
+				label = fieldLabel(field)
+			}
+			path, pointer := fieldPaths(modelType, err.StructNamespace(), err.Namespace())
+			param, paramLabel := err.Param(), ""
+			if name, label, ok := crossFieldParam(modelType, err.StructNamespace(), err.Tag(), err.Param()); ok {
+				param, paramLabel = name, label
+			}
 			detail := ErrorDetailElement{
 				Field:       err.Field(),
 				Tag:         err.Tag(),
-				Param:       err.Param(),
+				Param:       param,
 				Kind:        err.Kind(),
 				Value:       err.Value(),
 				StructField: err.StructField(),
+				Label:       label,
+				Path:        path,
+				Pointer:     pointer,
+
+				paramLabel:      paramLabel,
+				structNamespace: fieldNamespace(modelType, err.StructNamespace()),
 			}
 			errorDetail = append(errorDetail, &detail)
 		}
@@ -102,6 +103,21 @@ func ValidateStruct(model any) error {
 	return nil
 }
 
+// appendErrorDetail appends elements to err if it is nil or *ErrorDetail, other errors are returned as is
+func appendErrorDetail(err error, elements ...*ErrorDetailElement) error {
+	if len(elements) == 0 {
+		return err
+	}
+	if err == nil {
+		errorDetail := ErrorDetail(elements)
+		return &errorDetail
+	}
+	if errorDetail, ok := err.(*ErrorDetail); ok {
+		*errorDetail = append(*errorDetail, elements...)
+	}
+	return err
+}
+
 // ValidateQuery parse, set default and validate query into model
 func ValidateQuery(c *fiber.Ctx, model any) error {
 	// parse query into struct
@@ -111,19 +127,35 @@ func ValidateQuery(c *fiber.Ctx, model any) error {
 		return BadRequest(err.Error())
 	}
 
+	// normalize by mod tag
+	Normalize(model)
+
 	// set default value
 	err = defaults.Set(model)
 	if err != nil {
 		return err
 	}
 
-	// Validate
-	return ValidateStruct(model)
+	// Validate, including filter and sort fields, then async validations
+	err = appendErrorDetail(ValidateStruct(model), validateFilter(model)...)
+	return withAsyncValidation(c.UserContext(), model, err)
 }
 
 // ValidateBody parse, set default and validate body based on Content-Type.
 // It supports json, xml and form only when struct tag exists; if empty, using defaults.
+// Decoders registered by RegisterBodyDecoder, e.g. msgpack and cbor, are also supported.
+// Uploaded files of multipart form are bound into *multipart.FileHeader fields and validated by `file` tag.
 func ValidateBody(c *fiber.Ctx, model any) error {
+	return validateBody(c, model, StrictBody)
+}
+
+// ValidateBodyStrict is ValidateBody in strict mode, which rejects unknown fields,
+// duplicate keys and trailing data in json body, regardless of StrictBody.
+func ValidateBodyStrict(c *fiber.Ctx, model any) error {
+	return validateBody(c, model, true)
+}
+
+func validateBody(c *fiber.Ctx, model any, strict bool) error {
 	body := c.Body()
 
 	// empty request body, return default value
@@ -131,19 +163,38 @@ func ValidateBody(c *fiber.Ctx, model any) error {
 		return defaults.Set(model)
 	}
 
-	// parse json, xml and form by fiber.BodyParser into struct
+	// check json body before parsing, to report the offending path
+	if strict && strings.HasSuffix(mediaType(c), "json") {
+		errorDetail, err := checkStrictJSON(body, reflect.TypeOf(model))
+		if err != nil {
+			return err
+		}
+		if len(errorDetail) > 0 {
+			return &errorDetail
+		}
+	}
+
+	// parse by registered decoders, or parse json, xml and form by fiber.BodyParser into struct
 	// see https://docs.gofiber.io/api/ctx/#bodyparser
-	err := c.BodyParser(model)
+	err := parseBody(c, model)
 	if err != nil {
-		return BadRequest(err.Error())
+		return err
 	}
 
+	// normalize by mod tag
+	Normalize(model)
+
 	// set default value
 	err = defaults.Set(model)
 	if err != nil {
 		return err
 	}
 
-	// Validate
-	return ValidateStruct(model)
+	// Validate, including uploaded files, then async validations
+	fileErrors, err := validateFiles(model)
+	if err != nil {
+		return err
+	}
+	err = appendErrorDetail(ValidateStruct(model), fileErrors...)
+	return withAsyncValidation(c.UserContext(), model, err)
 }
@@ -0,0 +1,157 @@
+package common
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rivo/uniseg"
+)
+
+// SensitiveDictionary checks whether content contains sensitive words, used by `sensitive` tag.
+// Implement it to use an external dictionary or service.
+type SensitiveDictionary interface {
+	Contains(content string) bool
+}
+
+// Sensitive is the dictionary used by `sensitive` tag, empty by default
+var Sensitive SensitiveDictionary = NewWordDictionary()
+
+type wordDictionary []string
+
+// NewWordDictionary returns a case-insensitive dictionary that matches any of words as substring
+func NewWordDictionary(words ...string) SensitiveDictionary {
+	dictionary := make(wordDictionary, 0, len(words))
+	for _, word := range words {
+		if word != "" {
+			dictionary = append(dictionary, strings.ToLower(word))
+		}
+	}
+	return dictionary
+}
+
+func (d wordDictionary) Contains(content string) bool {
+	content = strings.ToLower(content)
+	for _, word := range d {
+		if strings.Contains(content, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// CampusEmailDomains are the domains accepted by `campus_email` tag without param
+var CampusEmailDomains = []string{"fudan.edu.cn", "m.fudan.edu.cn"}
+
+var (
+	cnMobilePattern = regexp.MustCompile(`^(?:\+?86)?1[3-9]\d{9}$`)
+	hexColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+)
+
+var emojiTable = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x2600, Hi: 0x27bf, Stride: 1},
+		{Lo: 0x2b00, Hi: 0x2bff, Stride: 1},
+		{Lo: 0xfe0f, Hi: 0xfe0f, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f000, Hi: 0x1faff, Stride: 1},
+	},
+}
+
+// bidiControlTable are the invisible bidi controls that can be used to spoof text
+var bidiControlTable = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x202a, Hi: 0x202e, Stride: 1},
+		{Lo: 0x2066, Hi: 0x2069, Stride: 1},
+	},
+}
+
+// validations are the bundled tags registered by RegisterValidations
+var validations = map[string]validator.Func{
+	"min_runes": func(fl validator.FieldLevel) bool {
+		return compareLength(fl, func(s string) int { return len([]rune(s)) }, false)
+	},
+	"max_runes": func(fl validator.FieldLevel) bool {
+		return compareLength(fl, func(s string) int { return len([]rune(s)) }, true)
+	},
+	"min_graphemes": func(fl validator.FieldLevel) bool {
+		return compareLength(fl, uniseg.GraphemeClusterCount, false)
+	},
+	"max_graphemes": func(fl validator.FieldLevel) bool {
+		return compareLength(fl, uniseg.GraphemeClusterCount, true)
+	},
+	"sensitive": func(fl validator.FieldLevel) bool {
+		return Sensitive == nil || !Sensitive.Contains(fl.Field().String())
+	},
+	"cn_mobile": func(fl validator.FieldLevel) bool {
+		return cnMobilePattern.MatchString(fl.Field().String())
+	},
+	"campus_email": func(fl validator.FieldLevel) bool {
+		email := fl.Field().String()
+		if Validate.Var(email, "email") != nil {
+			return false
+		}
+		domains := CampusEmailDomains
+		if fl.Param() != "" {
+			domains = strings.Fields(fl.Param())
+		}
+		domain := email[strings.LastIndexByte(email, '@')+1:]
+		for _, allowed := range domains {
+			if strings.EqualFold(domain, allowed) {
+				return true
+			}
+		}
+		return false
+	},
+	"safe_markdown": func(fl validator.FieldLevel) bool {
+		s := fl.Field().String()
+		return SanitizeHTML(s) == s
+	},
+	"hex_color": func(fl validator.FieldLevel) bool {
+		return hexColorPattern.MatchString(fl.Field().String())
+	},
+	"no_emoji": func(fl validator.FieldLevel) bool {
+		return strings.IndexFunc(fl.Field().String(), func(r rune) bool {
+			return unicode.Is(emojiTable, r)
+		}) < 0
+	},
+	"no_control": func(fl validator.FieldLevel) bool {
+		return strings.IndexFunc(fl.Field().String(), func(r rune) bool {
+			return (unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t') || unicode.Is(bidiControlTable, r)
+		}) < 0
+	},
+}
+
+// compareLength compares the length of a string field with param, as max if isMax else min
+func compareLength(fl validator.FieldLevel, length func(string) int, isMax bool) bool {
+	limit, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		panic("invalid param of " + fl.GetTag() + ": " + fl.Param())
+	}
+	if isMax {
+		return length(fl.Field().String()) <= limit
+	}
+	return length(fl.Field().String()) >= limit
+}
+
+// RegisterValidations registers the bundled validation tags for OpenTreeHole into Validate:
+//
+//	min_runes=n, max_runes=n          length in runes, the same as StripContent
+//	min_graphemes=n, max_graphemes=n  length in user-perceived characters
+//	sensitive                         no word in Sensitive dictionary
+//	cn_mobile                         chinese mobile number, optionally prefixed with +86
+//	campus_email[=domain ...]         email in CampusEmailDomains or the domains in param
+//	safe_markdown                     unchanged by SanitizeHTML, e.g. no script, event handler or javascript link
+//	hex_color                         #rgb, #rrggbb or #rrggbbaa
+//	no_emoji, no_control              no emoji; no control characters except \t, \r and \n
+func RegisterValidations() error {
+	for tag, fn := range validations {
+		if err := Validate.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
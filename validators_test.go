@@ -0,0 +1,67 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterValidations(t *testing.T) {
+	assert.Nil(t, RegisterValidations())
+
+	for _, c := range []struct {
+		value string
+		tag   string
+		valid bool
+	}{
+		{"你好", "max_runes=2", true},
+		{"你好!", "max_runes=2", false},
+		{"你", "min_runes=2", false},
+		{"👨‍👩‍👧", "max_graphemes=1", true},
+		{"👨‍👩‍👧", "max_runes=1", false},
+		{"é", "min_graphemes=2", false},
+		{"13812345678", "cn_mobile", true},
+		{"+8613812345678", "cn_mobile", true},
+		{"12812345678", "cn_mobile", false},
+		{"a@fudan.edu.cn", "campus_email", true},
+		{"a@M.FUDAN.EDU.CN", "campus_email", true},
+		{"a@gmail.com", "campus_email", false},
+		{"a@gmail.com", "campus_email=gmail.com qq.com", true},
+		{"fudan.edu.cn", "campus_email", false},
+		{"**bold** [link](https://example.com) <b>ok</b>", "safe_markdown", true},
+		{"<script>alert(1)</script>", "safe_markdown", false},
+		{`<img src=x onerror="alert(1)">`, "safe_markdown", false},
+		{"[a](javascript:alert(1))", "safe_markdown", false},
+		{`<a href="JavaScript:alert(1)">a</a>`, "safe_markdown", false},
+		{"<svg/onload=alert(1)>", "safe_markdown", false},
+		{`<a href="jav&#x61;script:alert(1)">a</a>`, "safe_markdown", false},
+		{"<a href=\"java\tscript:alert(1)\">a</a>", "safe_markdown", false},
+		{"[x](JaVaScRiPt&colon;alert(1))", "safe_markdown", false},
+		{"> quote & a < b, <a href=https://example.com>link</a>", "safe_markdown", true},
+		{"#fff", "hex_color", true},
+		{"#00ff00cc", "hex_color", true},
+		{"00ff00", "hex_color", false},
+		{"hello, 世界", "no_emoji", true},
+		{"hello 😀", "no_emoji", false},
+		{"a ❤️", "no_emoji", false},
+		{"line\nnext\ttab", "no_control", true},
+		{"a\x00b", "no_control", false},
+		{"abc‮dcba", "no_control", false},
+	} {
+		err := Validate.Var(c.value, c.tag)
+		assert.Equal(t, c.valid, err == nil, "%s %s", c.tag, c.value)
+	}
+}
+
+func TestSensitive(t *testing.T) {
+	assert.Nil(t, RegisterValidations())
+	defer func(dictionary SensitiveDictionary) { Sensitive = dictionary }(Sensitive)
+	Sensitive = NewWordDictionary("Bad", "")
+
+	type request struct {
+		Content string `json:"content" validate:"sensitive"`
+	}
+	assert.Nil(t, ValidateStruct(&request{Content: "good"}))
+	err := ValidateStruct(&request{Content: "so bad"})
+	assert.EqualValues(t, "content包含敏感词", err.Error())
+}